@@ -3,3 +3,256 @@
 // license that can be found in the LICENSE file.
 
 package cedar
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+// Match is a dictionary hit in the text found by the Aho-Corasick automaton
+type Match struct {
+	Start, End int // the byte offsets of the hit in the text, End is exclusive
+	Value      int // the value stored with the key
+	ID         int // the node id of the key, as returned by Jump
+}
+
+// AhoCorasick is the Aho-Corasick automaton built on the double-array trie,
+// the links are indexed by the node id of the Cedar.
+//
+// Match is safe for concurrent use as long as the trie is not modified,
+// the links are built once by the first caller after a modification.
+type AhoCorasick struct {
+	*Cedar
+
+	mu    sync.Mutex // serialize the lazy BuildLinks
+	table atomic.Pointer[acTable]
+}
+
+// acTable is the links of the automaton for the `gen` of the trie,
+// it is immutable once built.
+type acTable struct {
+	cd *Cedar
+
+	fails []int // the failure link, the node of the longest proper suffix
+	outs  []int // the output link, the node itself or the nearest suffix node that has a value
+	lens  []int // the depth of the node, which is the length of the key
+	mins  []int // the min value of the keys below the node, math.MaxInt if none
+	depth int   // the max of lens, the length of the longest key
+	gen   uint64
+}
+
+// NewAhoCorasick create the Aho-Corasick automaton on the `cd`,
+// a new Cedar is used if `cd` is nil.
+func NewAhoCorasick(cd *Cedar) *AhoCorasick {
+	if cd == nil {
+		cd = New()
+	}
+
+	return &AhoCorasick{Cedar: cd}
+}
+
+// BuildLinks compute the failure links and output links of the trie,
+// it should be called after the keys are inserted; Match will call it
+// when the trie has been modified since the last BuildLinks.
+func (ac *AhoCorasick) BuildLinks() {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	ac.table.Store(ac.build())
+}
+
+func (ac *AhoCorasick) build() *acTable {
+	n := ac.size
+	t := &acTable{
+		cd:    ac.Cedar,
+		fails: make([]int, n),
+		outs:  make([]int, n),
+		lens:  make([]int, n),
+		mins:  make([]int, n),
+		gen:   ac.Cedar.gen,
+	}
+
+	// breadth-first, so the failure node is always computed before its children
+	queue := []int{0}
+	for q := 0; q < len(queue); q++ {
+		from := queue[q]
+		t.mins[from] = math.MaxInt
+
+		ac.eachChild(from, func(to int, label byte) bool {
+			t.lens[to] = t.lens[from] + 1
+			t.depth = max(t.depth, t.lens[to])
+			if from != 0 {
+				t.fails[to] = t.step(t.fails[from], label)
+			}
+
+			t.outs[to] = t.outs[t.fails[to]]
+			if _, err := ac.Value(to); err == nil {
+				t.outs[to] = to
+			}
+
+			queue = append(queue, to)
+			return true
+		})
+	}
+
+	// the children are after their parent in the queue
+	for q := len(queue) - 1; q > 0; q-- {
+		to := queue[q]
+		m := t.mins[to]
+		if val, err := ac.Value(to); err == nil {
+			m = min(m, val)
+		}
+
+		from := int(ac.array[to].check)
+		t.mins[from] = min(t.mins[from], m)
+	}
+
+	return t
+}
+
+// links return the links of the current trie, which is built if it is stale
+func (ac *AhoCorasick) links() *acTable {
+	if t := ac.table.Load(); t != nil && t.gen == ac.Cedar.gen {
+		return t
+	}
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	// built by another caller while waiting for the lock
+	if t := ac.table.Load(); t != nil && t.gen == ac.Cedar.gen {
+		return t
+	}
+
+	t := ac.build()
+	ac.table.Store(t)
+	return t
+}
+
+// stale report whether the links `t` is built for an older trie
+func (t *acTable) stale() bool {
+	return t.gen != t.cd.gen
+}
+
+// step move the automaton from the `state` by the `label`,
+// following the failure links until the transition exists.
+func (t *acTable) step(state int, label byte) int {
+	for {
+		if to, ok := t.cd.childOf(state, label); ok {
+			return to
+		}
+
+		if state == 0 {
+			return 0
+		}
+		state = t.fails[state]
+	}
+}
+
+// MatchKind is the semantics of the hits reported by Match
+type MatchKind int
+
+const (
+	// MatchOverlapping report every hit, including the overlapping ones
+	MatchOverlapping MatchKind = iota
+	// MatchLeftmostFirst report the non-overlapping hits, the leftmost one
+	// is taken first, and the key of the lower value is preferred at the same
+	// start, so the value works as the priority such as the insertion order.
+	MatchLeftmostFirst
+	// MatchLeftmostLongest report the non-overlapping hits, the leftmost one
+	// is taken first, and the longest key is preferred at the same start.
+	MatchLeftmostLongest
+)
+
+// Match return the dictionary hits in the `text` by the `kind`, the default
+// is MatchOverlapping, which hits are ordered by the end offset, and the
+// longer one first; the non-overlapping hits are ordered by the offsets.
+func (ac *AhoCorasick) Match(text []byte, kind ...MatchKind) (ms []Match) {
+	t := ac.links()
+	if len(kind) > 0 && kind[0] != MatchOverlapping {
+		ms, _ = t.leftmost(text, 0, kind[0], true, ms)
+		return
+	}
+
+	_, ms = t.scan(0, text, 0, ms)
+	return
+}
+
+// better report whether the hit `m` is preferred to `c` by the leftmost `kind`
+func better(m, c Match, kind MatchKind) bool {
+	if m.Start != c.Start {
+		return m.Start < c.Start
+	}
+	if kind == MatchLeftmostFirst && m.Value != c.Value {
+		return m.Value < c.Value
+	}
+	return m.End > c.End
+}
+
+// final report whether the candidate `c` could not be beaten by the keys below
+// the `state`, which is the path of the text from the start of `c`.
+func (t *acTable) final(state int, c Match, kind MatchKind) bool {
+	if kind == MatchLeftmostFirst {
+		return t.mins[state] > c.Value
+	}
+	return t.mins[state] == math.MaxInt
+}
+
+// leftmost run the automaton from the root over the text, which is at the
+// `offset` of the whole input, and append the non-overlapping hits to ms.
+//
+// The best hit of the leftmost start is kept until no state could beat it,
+// then it is reported and the search restarts from the root at its end,
+// so only the bytes after the hit and before the state are scanned again.
+// If the text is not `final`, the hits that the following text could change
+// are not reported, and `keep` is the index of the text where the scan
+// should be restarted with the following text.
+func (t *acTable) leftmost(text []byte, offset int, kind MatchKind,
+	final bool, ms []Match) (_ []Match, keep int) {
+	state, has, cand := 0, false, Match{}
+	for i := 0; i <= len(text); i++ {
+		end := i == len(text)
+		if !end {
+			state = t.step(state, text[i])
+			// the first output is the longest, so it starts the leftmost
+			if o := t.outs[state]; o > 0 {
+				m := Match{Start: i + 1 - t.lens[o], End: i + 1, ID: o}
+				m.Value, _ = t.cd.Value(o)
+				if !has || better(m, cand, kind) {
+					cand, has = m, true
+				}
+			}
+		}
+
+		// no hit could start at or before the candidate, or beat it at its start,
+		// or the text is ended
+		if has && (end && final || !end && (i+1-t.lens[state] > cand.Start ||
+			i+1-t.lens[state] == cand.Start && t.final(state, cand, kind))) {
+			i, state, has = cand.End-1, 0, false
+			cand.Start += offset
+			cand.End += offset
+			ms = append(ms, cand)
+		}
+	}
+
+	keep = len(text) - t.lens[state]
+	if has {
+		keep = min(keep, cand.Start)
+	}
+	return ms, keep
+}
+
+// scan run the automaton from the `state` over the text, which is at
+// the `offset` of the whole input; it append the hits to ms and return
+// the state at the end of the text.
+func (t *acTable) scan(state int, text []byte, offset int, ms []Match) (int, []Match) {
+	for i, c := range text {
+		state = t.step(state, c)
+		for o := t.outs[state]; o > 0; o = t.outs[t.fails[o]] {
+			val, _ := t.cd.Value(o)
+			end := offset + i + 1
+			ms = append(ms, Match{Start: end - t.lens[o], End: end, Value: val, ID: o})
+		}
+	}
+
+	return state, ms
+}
@@ -0,0 +1,199 @@
+package cedar
+
+import (
+	"bytes"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestAhoCorasick(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		ac := NewAhoCorasick(New(reduced))
+		for i, key := range []string{"he", "she", "his", "hers", "太阳", "太阳系"} {
+			err := ac.Insert([]byte(key), i)
+			tt.Nil(t, err)
+		}
+
+		ms := ac.Match([]byte("ushers"))
+		tt.Equal(t, 3, len(ms))
+		hits := []Match{{1, 4, 1, 0}, {2, 4, 0, 0}, {2, 6, 3, 0}}
+		for i, m := range ms {
+			key, _ := ac.Jump([]byte("ushers"[m.Start:m.End]), 0)
+			hits[i].ID = key
+			tt.Equal(t, hits[i], m)
+		}
+
+		ms = ac.Match([]byte("我们的太阳系"))
+		tt.Equal(t, 2, len(ms))
+		tt.Equal(t, 4, ms[0].Value)
+		tt.Equal(t, "太阳", "我们的太阳系"[ms[0].Start:ms[0].End])
+		tt.Equal(t, 5, ms[1].Value)
+		tt.Equal(t, "太阳系", "我们的太阳系"[ms[1].Start:ms[1].End])
+
+		// the automaton is rebuilt after the trie is modified
+		err := ac.Delete([]byte("she"))
+		tt.Nil(t, err)
+		err = ac.Insert([]byte("us"), 6)
+		tt.Nil(t, err)
+		tt.Nil(t, ac.Validate())
+
+		ms = ac.Match([]byte("ushers"))
+		tt.Equal(t, 3, len(ms))
+		tt.Equal(t, 6, ms[0].Value)
+		tt.Equal(t, 0, ms[1].Value)
+		tt.Equal(t, 3, ms[2].Value)
+
+		tt.Equal(t, 0, len(ac.Match([]byte("xyz\x00"))))
+
+		// the trie is built from the sorted keys, then the links
+		ac = NewAhoCorasick(New(reduced))
+		err = ac.Build([][]byte{[]byte("he"), []byte("hers"), []byte("she")}, nil)
+		tt.Nil(t, err)
+		ac.BuildLinks()
+		ms = ac.Match([]byte("ushers"))
+		tt.Equal(t, 3, len(ms))
+		tt.Equal(t, []int{2, 0, 1}, []int{ms[0].Value, ms[1].Value, ms[2].Value})
+	}
+}
+
+func TestMatchKind(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		ac := NewAhoCorasick(New(reduced))
+		for i, key := range []string{"b", "abc", "abcd", "太阳", "太阳系", "阳系"} {
+			err := ac.Insert([]byte(key), i)
+			tt.Nil(t, err)
+		}
+
+		spans := func(text string, ms []Match) (ss []string) {
+			for _, m := range ms {
+				ss = append(ss, text[m.Start:m.End])
+			}
+			return
+		}
+
+		text := "abcd太阳系b"
+		tt.Equal(t, []string{"b", "abc", "abcd", "太阳", "太阳系", "阳系", "b"},
+			spans(text, ac.Match([]byte(text), MatchOverlapping)))
+		tt.Equal(t, []string{"abc", "太阳", "b"},
+			spans(text, ac.Match([]byte(text), MatchLeftmostFirst)))
+		tt.Equal(t, []string{"abcd", "太阳系", "b"},
+			spans(text, ac.Match([]byte(text), MatchLeftmostLongest)))
+
+		// the leftmost hit wins over the longer one starting later
+		text = "xbcd阳系"
+		tt.Equal(t, []string{"b", "阳系"},
+			spans(text, ac.Match([]byte(text), MatchLeftmostLongest)))
+		tt.Equal(t, 0, len(ac.Match(nil, MatchLeftmostFirst)))
+	}
+
+	// the value is the priority of the leftmost-first
+	for _, keys := range [][]string{{"Samwise", "Sam"}, {"Sam", "Samwise"}} {
+		ac := NewAhoCorasick(nil)
+		for i, key := range keys {
+			err := ac.Insert([]byte(key), i)
+			tt.Nil(t, err)
+		}
+
+		ms := ac.Match([]byte("Samwise"), MatchLeftmostFirst)
+		tt.Equal(t, 1, len(ms))
+		tt.Equal(t, len(keys[0]), ms[0].End)
+		ms = ac.Match([]byte("Samwise"), MatchLeftmostLongest)
+		tt.Equal(t, 1, len(ms))
+		tt.Equal(t, 7, ms[0].End)
+	}
+}
+
+func TestMatchKindRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randString := func(n int) []byte {
+		s := make([]byte, r.Intn(n)+1)
+		for i := range s {
+			s[i] = "abc"[r.Intn(3)]
+		}
+		return s
+	}
+
+	ac := NewAhoCorasick(nil)
+	for i := 0; i < 30; i++ {
+		err := ac.Insert(randString(5), r.Intn(100))
+		tt.Nil(t, err)
+	}
+
+	for i := 0; i < 100; i++ {
+		text := randString(50)
+		for _, kind := range []MatchKind{MatchLeftmostFirst, MatchLeftmostLongest} {
+			// restart the search after every taken hit
+			var want []Match
+			for pos := 0; pos < len(text); {
+				var best *Match
+				for _, id := range ac.PrefixMatch(text[pos:]) {
+					key, _ := ac.Key(id)
+					val, _ := ac.Value(id)
+					m := Match{Start: pos, End: pos + len(key), Value: val}
+					if best == nil || kind == MatchLeftmostLongest ||
+						val < best.Value || val == best.Value && m.End > best.End {
+						best = &m
+					}
+				}
+
+				if best == nil {
+					pos++
+					continue
+				}
+				want = append(want, *best)
+				pos = best.End
+			}
+
+			got := ac.Match(text, kind)
+			tt.Equal(t, len(want), len(got))
+			for j := range got {
+				got[j].ID = 0
+			}
+			tt.Equal(t, want, got, string(text))
+		}
+	}
+}
+
+func TestAhoCorasickConcurrent(t *testing.T) {
+	ac := NewAhoCorasick(nil)
+	for i, key := range []string{"he", "she", "his", "hers"} {
+		err := ac.Insert([]byte(key), i)
+		tt.Nil(t, err)
+	}
+
+	// the links are built once by the first of the concurrent callers
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tt.Equal(t, 3, len(ac.Match([]byte("ushers"))))
+		}()
+	}
+	wg.Wait()
+
+	tab := ac.table.Load()
+	tt.Equal(t, ac.size, len(tab.fails))
+	ac.Match(nil)
+	tt.True(t, tab == ac.table.Load())
+}
+
+func TestMatchKindLong(t *testing.T) {
+	ac := NewAhoCorasick(nil)
+	for i := 1; i <= 100; i++ {
+		err := ac.Insert(bytes.Repeat([]byte("a"), i), i)
+		tt.Nil(t, err)
+	}
+
+	text := bytes.Repeat([]byte("a"), 200*1000)
+	ms := ac.Match(text, MatchLeftmostLongest)
+	tt.Equal(t, 2000, len(ms))
+	tt.Equal(t, Match{Start: 100, End: 200, Value: 100, ID: ms[1].ID}, ms[1])
+
+	ms = ac.Match(text, MatchLeftmostFirst)
+	tt.Equal(t, len(text), len(ms))
+	tt.Equal(t, 1, ms[0].Value)
+}
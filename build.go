@@ -0,0 +1,465 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"bytes"
+	"iter"
+)
+
+// Build construct the empty trie from the keys sorted in the lexicographic
+// order. The nodes are placed bottom-up as the keys arrive: a node is closed
+// when the next key leaves its path, then all its children are known and
+// placed at once in the free slots, without the conflicts and the empty
+// rings of Insert, which are rebuilt at the end.
+// The `vals` are the values of the keys, the index of the key is used as
+// the value if `vals` is nil.
+//
+// The keys must not be empty or contain the byte 0, and the input is
+// checked before the trie is modified.
+func (cd *Cedar) Build(keys [][]byte, vals []int) error {
+	if vals != nil && len(vals) != len(keys) {
+		return ErrInvalidVal
+	}
+
+	nodes := 0
+	for i, key := range keys {
+		var prev []byte
+		if i > 0 {
+			prev = keys[i-1]
+		}
+		lcp, err := checkKey(prev, key)
+		if err != nil {
+			return err
+		}
+		if vals != nil && (vals[i] < 0 || vals[i] >= ValLimit) {
+			return ErrInvalidVal
+		}
+
+		// the new nodes of the key are the bytes after the common prefix,
+		// and the terminal node, the reduced trie needs it if the key
+		// has the children
+		nodes += len(key) - lcp
+		if !cd.Reduced || i > 0 && lcp == len(prev) {
+			nodes++
+		}
+	}
+
+	if !cd.empty() {
+		return ErrNotEmpty
+	}
+
+	if len(keys) > 0 {
+		// grow once for the nodes and the free slots left by the placing
+		cd.reserve(cd.size + nodes + nodes/16)
+		b := &builder{cd: cd}
+		for i, key := range keys {
+			val := i
+			if vals != nil {
+				val = vals[i]
+			}
+			b.add(key, val)
+		}
+		b.finish()
+	}
+	return nil
+}
+
+// BuildSeq is like Build but reads the sorted keys and values from `seq`,
+// the nodes are placed as the keys arrive and the keys are not kept,
+// so the iterator could reuse them, e.g. Cedar.All.
+// The trie is left empty if a key or a value is invalid.
+func (cd *Cedar) BuildSeq(seq iter.Seq2[[]byte, int]) error {
+	if !cd.empty() {
+		return ErrNotEmpty
+	}
+
+	b := &builder{cd: cd}
+	for key, val := range seq {
+		_, err := checkKey(b.path, key)
+		if err == nil && (val < 0 || val >= ValLimit) {
+			err = ErrInvalidVal
+		}
+		if err != nil {
+			cd.reset()
+			return err
+		}
+
+		b.add(key, val)
+	}
+
+	b.finish()
+	return nil
+}
+
+// checkKey check the key is valid and follows `prev` in the sorted order,
+// and return the length of their common prefix, `prev` is empty for the first key.
+func checkKey(prev, key []byte) (int, error) {
+	if len(key) == 0 || bytes.IndexByte(key, 0) >= 0 {
+		return 0, ErrInvalidKey
+	}
+
+	lcp := 0
+	for lcp < len(prev) && lcp < len(key) && prev[lcp] == key[lcp] {
+		lcp++
+	}
+
+	switch {
+	case lcp == len(key) && lcp == len(prev):
+		return lcp, ErrDuplicateKey
+	case lcp == len(key) || lcp < len(prev) && prev[lcp] > key[lcp]:
+		return lcp, ErrNotSorted
+	}
+	return lcp, nil
+}
+
+// empty report whether the trie has no key
+func (cd *Cedar) empty() bool {
+	return cd.eachChild(0, func(int, byte) bool { return false })
+}
+
+// reset make the trie empty, the options are kept
+func (cd *Cedar) reset() {
+	gen := cd.gen
+	*cd = *NewWithOptions(WithReduced(cd.Reduced),
+		WithOrdered(cd.ordered), WithMaxTrial(int(cd.maxTrial)))
+	cd.gen = gen + 1
+}
+
+// builder place the nodes of the sorted keys. The nodes on the path of
+// the last key are open, the closed nodes wait in `kids` for the placing
+// with their siblings; the check of a node is known when its parent is
+// placed, so it is set then by walking the children.
+//
+// The free slots are zero while building, they are taken by the cursor
+// `cur` and from the new blocks at `top`, the block 0 is kept for the
+// children of the root which are placed at the end.
+type builder struct {
+	cd     *Cedar
+	path   []byte     // the last key
+	open   []openNode // open[d] is the node of path[:d]
+	kids   []kid
+	labels []byte // the labels of the placing node
+	count  int
+	cur    int // the slots before `cur` are used
+	top    int // the slots from `top` are free
+}
+
+// openNode is the node on the path of the last key
+type openNode struct {
+	kids int // the index of its first child in kids
+	val  int // the value of the key ends at the node, NoVal if none
+}
+
+// kid is a closed node, it has the children if its baseV is the base
+type kid struct {
+	baseV nodeInt
+	label byte
+	head  byte // the first label of its children
+}
+
+// add the key after the last key, it is checked by the caller
+func (b *builder) add(key []byte, val int) {
+	if b.count == 0 {
+		b.start()
+	}
+
+	lcp := 0
+	for lcp < len(b.path) && lcp < len(key) && b.path[lcp] == key[lcp] {
+		lcp++
+	}
+	b.closeTo(lcp)
+
+	for _, label := range key[lcp:] {
+		b.kids = append(b.kids, kid{label: label})
+		b.open = append(b.open, openNode{kids: len(b.kids), val: NoVal})
+	}
+	b.open[len(key)].val = val
+	b.path = append(b.path[:lcp], key[lcp:]...)
+	b.count++
+}
+
+// start clear the free slots of the empty trie, and open the root
+func (b *builder) start() {
+	clear(b.cd.array[256:b.cd.size])
+	clear(b.cd.blocks[:b.cd.size>>8])
+	b.cur, b.top = 256, 256
+	b.open = append(b.open, openNode{kids: 0, val: NoVal})
+}
+
+// closeTo close the open nodes deeper than `depth`, the leaves and the
+// chains of the single children are placed here without the labels.
+func (b *builder) closeTo(depth int) {
+	cd := b.cd
+	for len(b.open) > depth+1 {
+		n := b.open[len(b.open)-1]
+		kids := len(b.kids) - n.kids
+		switch {
+		case kids == 0 && cd.Reduced:
+			// the leaf of the reduced trie holds the value
+			b.open = b.open[:len(b.open)-1]
+			b.kids[n.kids-1].baseV = nodeInt(n.val)
+			continue
+		case kids != 1 || n.val != NoVal:
+			b.close()
+			continue
+		}
+
+		// the single child is placed at the first free slot
+		for b.used(b.cur) {
+			b.cur++
+		}
+		if b.cur >= cd.capacity {
+			b.grow(b.cur)
+		}
+
+		b.open = b.open[:len(b.open)-1]
+		c, e := b.kids[n.kids], b.cur
+		cd.array[e] = Node{baseV: c.baseV, check: 1}
+		cd.nInfos[e] = NInfo{child: c.head}
+		cd.blocks[e>>8].num++
+		b.cur++
+		b.top = max(b.top, b.cur)
+		b.adopt(e, c)
+
+		k := &b.kids[n.kids-1]
+		k.head = c.label
+		if base := e ^ int(c.label); cd.Reduced {
+			k.baseV = nodeInt(-base - 1)
+		} else {
+			k.baseV = nodeInt(base)
+		}
+		b.kids = b.kids[:n.kids]
+	}
+}
+
+// close the last open node which has the terminal node or more children,
+// they are placed at once.
+func (b *builder) close() {
+	n := b.open[len(b.open)-1]
+	b.open = b.open[:len(b.open)-1]
+
+	labels := b.labels[:0]
+	if n.val != NoVal {
+		labels = append(labels, 0)
+	}
+	for _, c := range b.kids[n.kids:] {
+		labels = append(labels, c.label)
+	}
+	b.labels = labels
+
+	base := b.findBase(labels)
+	b.place(n, base)
+
+	k := &b.kids[n.kids-1]
+	k.head = labels[0]
+	k.baseV = nodeInt(base)
+	if b.cd.Reduced {
+		k.baseV = nodeInt(-base - 1)
+	}
+	b.kids = b.kids[:n.kids]
+}
+
+// place the terminal node and the children of `n` at the base,
+// their labels are in b.labels.
+func (b *builder) place(n openNode, base int) {
+	cd := b.cd
+	labels := b.labels
+
+	// kids[i] is the child of labels[i]
+	kids := b.kids[len(b.kids)-len(labels):]
+	for i, label := range labels {
+		var sibling byte
+		if i+1 < len(labels) {
+			sibling = labels[i+1]
+		}
+
+		// the check is set when `n` is placed, it is not 0 until then,
+		// so the slot is not free
+		e := base ^ int(label)
+		b.top = max(b.top, e+1)
+		cd.blocks[e>>8].num++
+		if label == 0 {
+			cd.array[e] = Node{baseV: nodeInt(n.val), check: 1}
+			cd.nInfos[e] = NInfo{sibling: sibling}
+			continue
+		}
+
+		cd.array[e] = Node{baseV: kids[i].baseV, check: 1}
+		cd.nInfos[e] = NInfo{sibling: sibling, child: kids[i].head}
+		b.adopt(e, kids[i])
+	}
+}
+
+// adopt set the check of the children of `k` which is placed at `e`
+func (b *builder) adopt(e int, k kid) {
+	cd := b.cd
+	if cd.Reduced && k.baseV >= 0 {
+		return
+	}
+
+	base := int(k.baseV)
+	if cd.Reduced {
+		base = -base - 1
+	}
+	for l := k.head; ; {
+		c := base ^ int(l)
+		cd.array[c].check = nodeInt(e)
+		if l = cd.nInfos[c].sibling; l == 0 {
+			return
+		}
+	}
+}
+
+// window is the number of the last blocks where the children are placed,
+// the free slots of the blocks before it are left to the single children.
+const window = 16
+
+// findBase return the base of the labels in the free slots, the free
+// slots of the last blocks are tried from the cursor, then a new block.
+// The num of the blocks is the number of the used slots while building.
+func (b *builder) findBase(labels []byte) int {
+	if len(labels) == 1 {
+		return b.free() ^ int(labels[0])
+	}
+
+	cd, last := b.cd, (b.top-1)>>8
+	for idx := max(1, b.cur>>8, last-window+1); idx <= last; idx++ {
+		if 256-int(cd.blocks[idx].num) < len(labels) {
+			continue
+		}
+
+		for e := max(idx<<8, b.cur); e < idx<<8+256; e++ {
+			if b.used(e) {
+				continue
+			}
+
+			base, ok := e^int(labels[0]), true
+			for _, label := range labels[1:] {
+				if b.used(base ^ int(label)) {
+					ok = false
+					break
+				}
+			}
+			if ok {
+				return base
+			}
+		}
+	}
+
+	base := (last + 1) << 8
+	b.grow(base)
+	return base
+}
+
+// free return the first free slot from the cursor
+func (b *builder) free() int {
+	for b.used(b.cur) {
+		b.cur++
+	}
+
+	b.grow(b.cur)
+	return b.cur
+}
+
+// used report whether the slot `e` is used, the slots from `top` are
+// not read, so the new memory is not touched until it is written
+func (b *builder) used(e int) bool {
+	return e < b.top && b.cd.array[e].check != 0
+}
+
+// grow the trie to hold the block of the slot `e`
+func (b *builder) grow(e int) {
+	if e >= b.cd.capacity {
+		b.cd.reserve(max(e|255+1, b.cd.capacity*2))
+	}
+}
+
+// finish place the children of the root, and rebuild the empty rings
+// and the block lists.
+func (b *builder) finish() {
+	if b.count == 0 {
+		return
+	}
+	b.closeTo(0)
+
+	// the root stays at the base 0 as New
+	cd := b.cd
+	b.labels = b.labels[:0]
+	for _, c := range b.kids {
+		b.labels = append(b.labels, c.label)
+	}
+	b.place(b.open[0], 0)
+	cd.nInfos[0].sibling = b.labels[0]
+
+	var used [256]bool
+	used[0] = true
+	for _, label := range b.labels {
+		e := int(label)
+		used[e] = true
+		cd.array[e].check = 0
+	}
+
+	cd.size = max(cd.size, (b.top+255)&^255)
+	cd.blocksHeadFull, cd.blocksHeadClosed, cd.blocksHeadOpen = 0, 0, 0
+	for idx := 0; idx < cd.size>>8; idx++ {
+		b.ring(idx, used[:])
+
+		bl := &cd.blocks[idx]
+		switch {
+		case idx == 0:
+			// the root slot is counted as free in the block 0
+			bl.num++
+		case bl.num == 0:
+			cd.pushBlock(idx, &cd.blocksHeadFull, false)
+		case bl.num == 1:
+			cd.pushBlock(idx, &cd.blocksHeadClosed, cd.blocksHeadClosed == 0)
+		default:
+			cd.pushBlock(idx, &cd.blocksHeadOpen, cd.blocksHeadOpen == 0)
+		}
+	}
+
+	cd.count = b.count
+	cd.gen++
+}
+
+// ring link the free slots of the block `idx` as the empty ring, the full
+// block is not read, the used slots of the block 0 are in `used`.
+func (b *builder) ring(idx int, used []bool) {
+	cd := b.cd
+	bl := &cd.blocks[idx]
+	full := idx != 0 && bl.num == 256
+	*bl = Block{}
+	bl.init()
+	bl.num = 0
+	if full {
+		return
+	}
+
+	lo, first, last := idx<<8, -1, -1
+	for e := lo; e < lo+256; e++ {
+		if idx == 0 && used[e] || idx != 0 && cd.array[e].check != 0 {
+			continue
+		}
+
+		if first < 0 {
+			first = e
+		} else {
+			cd.array[last].check = nodeInt(-e)
+			cd.array[e].baseV = nodeInt(-last)
+		}
+		last = e
+		bl.num++
+	}
+
+	if first < 0 {
+		// the full block 0 keeps the last child of the root as Insert does
+		bl.eHead = blockInt(b.labels[len(b.labels)-1])
+		return
+	}
+	cd.array[first].baseV = nodeInt(-last)
+	cd.array[last].check = nodeInt(-first)
+	bl.eHead = blockInt(first)
+}
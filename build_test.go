@@ -0,0 +1,224 @@
+package cedar
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestBuild(t *testing.T) {
+	sorted := append([]string(nil), words...)
+	sort.Strings(sorted)
+	keys := make([][]byte, len(sorted))
+	vals := make([]int, len(sorted))
+	for i, word := range sorted {
+		keys[i] = []byte(word)
+		vals[i] = i * 10
+	}
+
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		err := d.Build(keys, vals)
+		tt.Nil(t, err)
+		tt.Nil(t, d.Validate())
+		tt.Equal(t, len(keys), d.Len())
+
+		for i, word := range sorted {
+			val, err := d.Get([]byte(word))
+			tt.Nil(t, err)
+			tt.Equal(t, i*10, val)
+		}
+		_, err = d.Get([]byte("abcde"))
+		tt.NotNil(t, err)
+
+		ids := d.PrefixMatch([]byte("this is a cedar."))
+		tt.Equal(t, 3, len(ids))
+		ids = d.PrefixPredict([]byte("太阳系"))
+		tt.Equal(t, 9, len(ids))
+
+		var walked []string
+		d.Walk(func(key []byte, val int) bool {
+			walked = append(walked, string(key))
+			return true
+		})
+		tt.Equal(t, sorted, walked)
+
+		// the built trie is still updatable
+		for i := 0; i < 1000; i++ {
+			err = d.Insert([]byte("key"+strconv.Itoa(i)), i)
+			tt.Nil(t, err)
+		}
+		for i, word := range sorted {
+			if i%2 == 0 {
+				err = d.Delete([]byte(word))
+				tt.Nil(t, err)
+			}
+		}
+		for i, word := range sorted {
+			val, err := d.Get([]byte(word))
+			if i%2 == 0 {
+				tt.NotNil(t, err)
+			} else {
+				tt.Nil(t, err)
+				tt.Equal(t, i*10, val)
+			}
+		}
+		tt.Nil(t, d.Validate())
+		val, err := d.Get([]byte("key999"))
+		tt.Nil(t, err)
+		tt.Equal(t, 999, val)
+
+		// the index is the value without the vals
+		d = New(reduced)
+		err = d.BuildSeq(New().All())
+		tt.Nil(t, err)
+		err = d.Build(keys, nil)
+		tt.Nil(t, err)
+		val, err = d.Get([]byte("cedar"))
+		tt.Nil(t, err)
+		tt.Equal(t, 6, val)
+
+		nd := New(reduced)
+		err = nd.BuildSeq(d.All())
+		tt.Nil(t, err)
+		val, err = nd.Get([]byte("this is"))
+		tt.Nil(t, err)
+		tt.Equal(t, 8, val)
+	}
+}
+
+func TestBuildInvalid(t *testing.T) {
+	d := New()
+	err := d.Build([][]byte{[]byte("b"), []byte("a")}, nil)
+	tt.Equal(t, ErrNotSorted, err)
+	err = d.Build([][]byte{[]byte("a"), []byte("a")}, nil)
+	tt.Equal(t, ErrDuplicateKey, err)
+	err = d.Build([][]byte{[]byte("a"), []byte("")}, nil)
+	tt.Equal(t, ErrInvalidKey, err)
+	err = d.Build([][]byte{[]byte("a")}, []int{-1})
+	tt.Equal(t, ErrInvalidVal, err)
+	err = d.Build([][]byte{[]byte("a")}, []int{1, 2})
+	tt.Equal(t, ErrInvalidVal, err)
+
+	err = d.Insert([]byte("a"), 1)
+	tt.Nil(t, err)
+	err = d.Build([][]byte{[]byte("b")}, nil)
+	tt.Equal(t, ErrNotEmpty, err)
+	err = d.BuildSeq(New().All())
+	tt.Equal(t, ErrNotEmpty, err)
+
+	// BuildSeq stops at the invalid key, and the trie is left empty
+	for _, reduced := range []bool{true, false} {
+		d = New(reduced)
+		err = d.BuildSeq(func(yield func([]byte, int) bool) {
+			for i := 0; i < 1000; i++ {
+				if !yield([]byte("key"+strconv.Itoa(1000-i)), i) {
+					return
+				}
+			}
+		})
+		tt.Equal(t, ErrNotSorted, err)
+		tt.Equal(t, 0, d.Len())
+		tt.Equal(t, reduced, d.Reduced)
+		tt.Nil(t, d.Validate())
+
+		err = d.BuildSeq(func(yield func([]byte, int) bool) {
+			_ = yield([]byte("a"), 1) && yield([]byte("b"), ValLimit)
+		})
+		tt.Equal(t, ErrInvalidVal, err)
+		tt.Equal(t, 0, d.Len())
+		_, err = d.Get([]byte("a"))
+		tt.Equal(t, ErrNoKey, err)
+
+		err = d.Insert([]byte("a"), 1)
+		tt.Nil(t, err)
+		tt.Nil(t, d.Validate())
+	}
+}
+
+func TestBuildRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, alphabet := range []int{2, 16, 255} {
+		set := make(map[string]bool)
+		for len(set) < 5000 {
+			key := make([]byte, 1+r.Intn(12))
+			for i := range key {
+				key[i] = byte(1 + r.Intn(alphabet))
+			}
+			set[string(key)] = true
+		}
+
+		keys := make([][]byte, 0, len(set))
+		for key := range set {
+			keys = append(keys, []byte(key))
+		}
+		sort.Slice(keys, func(i, j int) bool {
+			return bytes.Compare(keys[i], keys[j]) < 0
+		})
+
+		for _, reduced := range []bool{true, false} {
+			want := New(reduced)
+			for i, key := range keys {
+				err := want.Insert(key, i)
+				tt.Nil(t, err)
+			}
+
+			// the emptied trie is built again in its free slots
+			d := New(reduced)
+			for _, key := range keys[:100] {
+				err := d.Insert(key, 0)
+				tt.Nil(t, err)
+			}
+			for _, key := range keys[:100] {
+				err := d.Delete(key)
+				tt.Nil(t, err)
+			}
+			err := d.Build(keys, nil)
+			tt.Nil(t, err)
+			tt.Nil(t, d.Validate())
+			tt.Equal(t, len(keys), d.Len())
+			tt.Equal(t, collect(want), collect(d))
+
+			// the key buffer is reused by the iterator
+			nd := New(reduced)
+			err = nd.BuildSeq(func(yield func([]byte, int) bool) {
+				var buf []byte
+				for i, key := range keys {
+					buf = append(buf[:0], key...)
+					if !yield(buf, i) {
+						return
+					}
+				}
+			})
+			tt.Nil(t, err)
+			tt.Nil(t, nd.Validate())
+			tt.Equal(t, collect(want), collect(nd))
+
+			// the built trie is still updatable
+			for i, key := range keys {
+				if i%3 == 0 {
+					err = nd.Delete(key)
+					tt.Nil(t, err)
+				}
+			}
+			for i := 0; i < 1000; i++ {
+				err = nd.Insert([]byte("key"+strconv.Itoa(i)), i)
+				tt.Nil(t, err)
+			}
+			tt.Nil(t, nd.Validate())
+			tt.Equal(t, len(keys)-(len(keys)+2)/3+1000, nd.Len())
+		}
+	}
+}
+
+// collect return the keys and values of the trie in the order
+func collect(d *Cedar) (kvs []string) {
+	for key, val := range d.All() {
+		kvs = append(kvs, string(key)+"="+strconv.Itoa(val))
+	}
+	return
+}
@@ -13,28 +13,28 @@ type NInfo struct {
 // "An efficient implementation of trie structures"
 // https://dl.acm.org/citation.cfm?id=146691
 type Node struct {
-	baseV, check int // int32
+	baseV, check nodeInt
 }
 
 func (n *Node) base(reduced ...bool) int {
 	if !isReduced(reduced...) {
-		return n.baseV
+		return int(n.baseV)
 	}
 
-	return -(n.baseV + 1)
+	return -(int(n.baseV) + 1)
 }
 
 // Block stores the linked-list pointers and the stats info for blocks.
 //
-// Because of type conversion, this version all int16 and int32 uses int,
-// witch will be optimized in the next version.
+// The fields use int by default, and int32 and int16 as the C++ cedar
+// with the `cedar32` build tag, see layout32.go.
 type Block struct {
-	prev   int // int32   // previous block's index, 3 bytes width
-	next   int // next block's index, 3 bytes width
-	num    int // the number of slots that is free, the range is 0-256
-	reject int // a heuristic number to make the search for free space faster...
-	trial  int // the number of times this block has been probed by `find_places` for the free block.
-	eHead  int // the index of the first empty elemenet in this block
+	prev   blockInt // previous block's index, 3 bytes width
+	next   blockInt // next block's index, 3 bytes width
+	num    slotInt  // the number of slots that is free, the range is 0-256
+	reject slotInt  // a heuristic number to make the search for free space faster...
+	trial  slotInt  // the number of times this block has been probed by `find_places` for the free block.
+	eHead  blockInt // the index of the first empty elemenet in this block
 }
 
 func (b *Block) init() {
@@ -50,7 +50,7 @@ type Cedar struct {
 	array  []Node // storing the `base` and `check` info from the original paper.
 	nInfos []NInfo
 	blocks []Block
-	reject [257]int
+	reject [257]slotInt
 
 	blocksHeadFull   int // the index of the first 'Full' block, 0 means no 'Full' block
 	blocksHeadClosed int // the index of the first 'Closed' block, 0 means no ' Closed' block
@@ -59,12 +59,16 @@ type Cedar struct {
 	capacity int
 	size     int
 	ordered  bool
-	maxTrial int // the parameter for cedar, it could be tuned for more, but the default is 1.
+	maxTrial slotInt // the parameter for cedar, it could be tuned by WithMaxTrial, the default is 1.
+
+	count int    // the number of keys
+	gen   uint64 // bumped on every modification, used to detect the stale automaton
+
+	maxes   []nodeInt // the max value of the subtree of the nodes, used by TopK
+	maxGens []uint64  // the gen+1 of the maxes of the nodes
 }
 
 const (
-	// ValLimit cedar value limit
-	ValLimit = int(^uint(0) >> 1)
 	// NoVal not have value
 	NoVal = -1
 )
@@ -72,20 +76,19 @@ const (
 // type PrefixIter struct {
 // }
 
-// New initialize the Cedar for further use
+// New initialize the Cedar for further use,
+// see NewWithOptions for the other options.
 func New(reduced ...bool) *Cedar {
-	cd := Cedar{
-		Reduced: isReduced(reduced...),
-
-		array:  make([]Node, 256),
-		nInfos: make([]NInfo, 256),
-		blocks: make([]Block, 1),
+	return NewWithOptions(WithReduced(isReduced(reduced...)))
+}
 
-		capacity: 256,
-		size:     256,
-		ordered:  true,
-		maxTrial: 1,
-	}
+// init initialize the first block of the trie
+func (cd *Cedar) init() {
+	cd.array = make([]Node, 256)
+	cd.nInfos = make([]NInfo, 256)
+	cd.blocks = make([]Block, 1)
+	cd.capacity = 256
+	cd.size = 256
 
 	if !cd.Reduced {
 		cd.array[0] = Node{baseV: 0, check: -1}
@@ -94,7 +97,7 @@ func New(reduced ...bool) *Cedar {
 	}
 	// make `baseV` point to the previous element, and make `check` point to the next element
 	for i := 1; i < 256; i++ {
-		cd.array[i] = Node{baseV: -(i - 1), check: -(i + 1)}
+		cd.array[i] = Node{baseV: nodeInt(-(i - 1)), check: nodeInt(-(i + 1))}
 	}
 	// make them link as a cyclic doubly-linked list
 	cd.array[1].baseV = -255
@@ -104,10 +107,8 @@ func New(reduced ...bool) *Cedar {
 	cd.blocks[0].init()
 
 	for i := 0; i <= 256; i++ {
-		cd.reject[i] = i + 1
+		cd.reject[i] = slotInt(i + 1)
 	}
-
-	return &cd
 }
 
 // follow To move in the trie by following the `label`, and insert the node if the node is not there,
@@ -129,7 +130,7 @@ func (cd *Cedar) follow(from int, label byte) (to int) {
 
 	// the node is already there and the ownership is not `from`,
 	// therefore a conflict.
-	if cd.array[to].check != from {
+	if int(cd.array[to].check) != from {
 		// call `resolve` to relocate.
 		to = cd.resolve(from, base, label)
 	}
@@ -161,8 +162,8 @@ func (cd *Cedar) popENode(base, from int, label byte) int {
 		cd.array[-arr.baseV].check = arr.check
 		cd.array[-arr.check].baseV = arr.baseV
 
-		if e == b.eHead {
-			b.eHead = -arr.check
+		if e == int(b.eHead) {
+			b.eHead = blockInt(-arr.check)
 		}
 
 		if idx != 0 && b.num == 1 && b.trial != cd.maxTrial {
@@ -178,18 +179,18 @@ func (cd *Cedar) popENode(base, from int, label byte) int {
 		} else {
 			cd.array[e].baseV = 0
 		}
-		cd.array[e].check = from
+		cd.array[e].check = nodeInt(from)
 		if base < 0 {
-			cd.array[from].baseV = e ^ int(label)
+			cd.array[from].baseV = nodeInt(e ^ int(label))
 		}
 
 		return e
 	}
 
-	cd.array[e].baseV = ValLimit
-	cd.array[e].check = from
+	cd.array[e].baseV = nodeInt(ValLimit)
+	cd.array[e].check = nodeInt(from)
 	if base < 0 {
-		cd.array[from].baseV = -(e ^ int(label)) - 1
+		cd.array[from].baseV = nodeInt(-(e ^ int(label)) - 1)
 	}
 
 	return e
@@ -203,22 +204,22 @@ func (cd *Cedar) pushENode(e int) {
 	b.num++
 
 	if b.num == 1 {
-		b.eHead = e
-		cd.array[e] = Node{baseV: -e, check: -e}
+		b.eHead = blockInt(e)
+		cd.array[e] = Node{baseV: nodeInt(-e), check: nodeInt(-e)}
 
 		if idx != 0 {
 			// Move the block from 'Full' to 'Closed' since it has one free slot now.
 			cd.transferBlock(idx, &cd.blocksHeadFull, &cd.blocksHeadClosed)
 		}
 	} else {
-		prev := b.eHead
-		next := -cd.array[prev].check
+		prev := int(b.eHead)
+		next := -int(cd.array[prev].check)
 
 		// Insert to the edge immediately after the e_head
-		cd.array[e] = Node{baseV: -prev, check: -next}
+		cd.array[e] = Node{baseV: nodeInt(-prev), check: nodeInt(-next)}
 
-		cd.array[prev].check = -e
-		cd.array[next].baseV = -e
+		cd.array[prev].check = nodeInt(-e)
+		cd.array[next].baseV = nodeInt(-e)
 
 		// Move the block from 'Closed' to 'Open' since it has more than one free slot now.
 		if b.num == 2 || b.trial == cd.maxTrial {
@@ -322,11 +323,11 @@ func (cd *Cedar) setChild(base int, c, label byte, flag bool) []byte {
 // For the case where only one free slot is needed
 func (cd *Cedar) findPlace() int {
 	if cd.blocksHeadClosed != 0 {
-		return cd.blocks[cd.blocksHeadClosed].eHead
+		return int(cd.blocks[cd.blocksHeadClosed].eHead)
 	}
 
 	if cd.blocksHeadOpen != 0 {
-		return cd.blocks[cd.blocksHeadOpen].eHead
+		return int(cd.blocks[cd.blocksHeadOpen].eHead)
 	}
 
 	// the block is not enough, resize it and allocate it.
@@ -348,8 +349,8 @@ func (cd *Cedar) findPlaces(child []byte) int {
 }
 
 func (cd *Cedar) listIdx(idx int, child []byte) int {
-	n := len(child)
-	bo := cd.blocks[cd.blocksHeadOpen].prev
+	n := slotInt(len(child))
+	bo := int(cd.blocks[cd.blocksHeadOpen].prev)
 
 	// only proceed if the free slots are more than the number of children. Also, we
 	// save the minimal number of attempts to fail in the `reject`, it only worths to
@@ -371,7 +372,7 @@ func (cd *Cedar) listIdx(idx int, child []byte) int {
 			cd.reject[b.num] = b.reject
 		}
 
-		idxN := b.next
+		idxN := int(b.next)
 		b.trial++
 		// move this block to the 'Closed' block list since it has reached the max_trial
 		if b.trial == cd.maxTrial {
@@ -390,20 +391,20 @@ func (cd *Cedar) listIdx(idx int, child []byte) int {
 }
 
 func (cd *Cedar) listEHead(b *Block, child []byte) int {
-	for e := b.eHead; ; {
+	for e := int(b.eHead); ; {
 		base := e ^ int(child[0])
 		// iterate through the children to see if they are available: (check < 0)
 		for i := 0; cd.array[base^int(child[i])].check < 0; i++ {
 			if i == len(child)-1 {
 				// we have found the available block.
-				b.eHead = e
+				b.eHead = blockInt(e)
 				return e
 			}
 		}
 
 		// save the next free block's information in `check`
-		e = -cd.array[e].check
-		if e == b.eHead {
+		e = -int(cd.array[e].check)
+		if e == int(b.eHead) {
 			break
 		}
 	}
@@ -417,7 +418,7 @@ func (cd *Cedar) resolve(fromN, baseN int, labelN byte) int {
 	toPn := baseN ^ int(labelN)
 
 	// the `base` and `from` for the conflicting one.
-	fromP := cd.array[toPn].check
+	fromP := int(cd.array[toPn].check)
 	baseP := cd.array[fromP].base(cd.Reduced)
 
 	// whether to replace siblings of newly added
@@ -460,9 +461,9 @@ func (cd *Cedar) resolve(fromN, baseN int, labelN byte) int {
 
 	// #[cfg(feature != "reduced-trie")]
 	if !cd.Reduced {
-		cd.array[from].baseV = base
+		cd.array[from].baseV = nodeInt(base)
 	} else {
-		cd.array[from].baseV = -base - 1
+		cd.array[from].baseV = nodeInt(-base - 1)
 	}
 
 	base, labelN, toPn = cd.listN(base, from, nbase, fromN, toPn,
@@ -509,11 +510,11 @@ func (cd *Cedar) listN(base, from, nbase, fromN, toPn int,
 			// this node has children, fix their check
 			c := cd.nInfos[newTo].child
 			cd.nInfos[to].child = c
-			cd.array[arr.base(cd.Reduced)^int(c)].check = to
+			cd.array[arr.base(cd.Reduced)^int(c)].check = nodeInt(to)
 
 			c = cd.nInfos[arr.base(cd.Reduced)^int(c)].sibling
 			for c != 0 {
-				cd.array[arr.base(cd.Reduced)^int(c)].check = to
+				cd.array[arr.base(cd.Reduced)^int(c)].check = nodeInt(to)
 				c = cd.nInfos[arr.base(cd.Reduced)^int(c)].sibling
 			}
 		}
@@ -535,9 +536,9 @@ func (cd *Cedar) listN(base, from, nbase, fromN, toPn int,
 					arrs.baseV = 0
 				}
 			} else {
-				arrs.baseV = ValLimit
+				arrs.baseV = nodeInt(ValLimit)
 			}
-			arrs.check = fromN
+			arrs.check = nodeInt(fromN)
 		} else {
 			cd.pushENode(newTo)
 		}
@@ -558,7 +559,7 @@ func (cd *Cedar) popBlock(idx int, from *int, last bool) {
 	cd.blocks[b.prev].next = b.next
 	cd.blocks[b.next].prev = b.prev
 	if idx == *from {
-		*from = b.next
+		*from = int(b.next)
 	}
 }
 
@@ -567,43 +568,31 @@ func (cd *Cedar) popBlock(idx int, from *int, last bool) {
 func (cd *Cedar) pushBlock(idx int, to *int, empty bool) {
 	b := &cd.blocks[idx]
 	if empty {
-		*to, b.prev, b.next = idx, idx, idx
+		*to, b.prev, b.next = idx, blockInt(idx), blockInt(idx)
 		return
 	}
 
 	tailTo := &cd.blocks[*to].prev
 	b.prev = *tailTo
-	b.next = *to
-	*to, *tailTo, cd.blocks[*tailTo].next = idx, idx, idx
+	b.next = blockInt(*to)
+	*to, *tailTo, cd.blocks[*tailTo].next = idx, blockInt(idx), blockInt(idx)
 }
 
 // Reallocate more spaces so that we have more free blocks.
 func (cd *Cedar) addBlock() int {
 	if cd.size == cd.capacity {
-		cd.capacity += cd.capacity
-
-		array := cd.array
-		cd.array = make([]Node, cd.capacity)
-		copy(cd.array, array)
-
-		nInfos := cd.nInfos
-		cd.nInfos = make([]NInfo, cd.capacity)
-		copy(cd.nInfos, nInfos)
-
-		blocks := cd.blocks
-		cd.blocks = make([]Block, cd.capacity>>8)
-		copy(cd.blocks, blocks)
+		cd.reserve(cd.capacity + cd.capacity)
 	}
 
 	cd.blocks[cd.size>>8].init()
-	cd.blocks[cd.size>>8].eHead = cd.size
+	cd.blocks[cd.size>>8].eHead = blockInt(cd.size)
 
 	// make it a doubley linked list
-	cd.array[cd.size] = Node{baseV: -(cd.size + 255), check: -(cd.size + 1)}
+	cd.array[cd.size] = Node{baseV: nodeInt(-(cd.size + 255)), check: nodeInt(-(cd.size + 1))}
 	for i := cd.size + 1; i < cd.size+255; i++ {
-		cd.array[i] = Node{baseV: -(i - 1), check: -(i + 1)}
+		cd.array[i] = Node{baseV: nodeInt(-(i - 1)), check: nodeInt(-(i + 1))}
 	}
-	cd.array[cd.size+255] = Node{baseV: -(cd.size + 254), check: -cd.size}
+	cd.array[cd.size+255] = Node{baseV: nodeInt(-(cd.size + 254)), check: nodeInt(-cd.size)}
 
 	// append to block Open
 	cd.pushBlock(cd.size>>8, &cd.blocksHeadOpen, cd.blocksHeadOpen == 0)
@@ -611,10 +600,32 @@ func (cd *Cedar) addBlock() int {
 	return cd.size>>8 - 1
 }
 
+// reserve reallocate the spaces to hold at least n slots at once,
+// the capacity is rounded up to the block size.
+func (cd *Cedar) reserve(n int) {
+	n = (n + 255) &^ 255
+	if n <= cd.capacity {
+		return
+	}
+	cd.capacity = n
+
+	array := cd.array
+	cd.array = make([]Node, cd.capacity)
+	copy(cd.array, array)
+
+	nInfos := cd.nInfos
+	cd.nInfos = make([]NInfo, cd.capacity)
+	copy(cd.nInfos, nInfos)
+
+	blocks := cd.blocks
+	cd.blocks = make([]Block, cd.capacity>>8)
+	copy(cd.blocks, blocks)
+}
+
 // transfer the block at idx from the linked-list of `from` to the linked-list of `to`,
 // specially handle the case where the destination linked-list is empty.
 func (cd *Cedar) transferBlock(idx int, from, to *int) {
 	b := cd.blocks[idx]
-	cd.popBlock(idx, from, idx == b.next) // b.next it's the last one if the next points to itself
+	cd.popBlock(idx, from, idx == int(b.next)) // b.next it's the last one if the next points to itself
 	cd.pushBlock(idx, to, *to == 0 && b.num != 0)
 }
@@ -1,7 +1,11 @@
 package cedar
 
 import (
+	"bytes"
+	"sort"
+	"strconv"
 	"testing"
+	"unsafe"
 
 	"github.com/vcaesar/tt"
 )
@@ -59,3 +63,108 @@ func BenchmarkDelete(t *testing.B) {
 
 	tt.BM(t, fn)
 }
+
+// benchKeys return n keys in a random order
+func benchKeys(n int) [][]byte {
+	keys := make([][]byte, n)
+	for i := range keys {
+		keys[i] = []byte("key" + strconv.Itoa(i*7919%n) + "/" + strconv.Itoa(i))
+	}
+	return keys
+}
+
+// reportLayout report the memory of the trie, run the layout benchmarks
+// with and without the `cedar32` build tag to compare the two layouts:
+//
+//	go test -run - -bench Layout
+//	go test -run - -bench Layout -tags cedar32
+func reportLayout(b *testing.B, d *Cedar, n int) {
+	bytes := len(d.array)*int(unsafe.Sizeof(Node{})) +
+		len(d.nInfos)*int(unsafe.Sizeof(NInfo{})) +
+		len(d.blocks)*int(unsafe.Sizeof(Block{}))
+
+	b.ReportMetric(float64(unsafe.Sizeof(Node{})), "B/node")
+	b.ReportMetric(float64(bytes)/float64(n), "B/key")
+}
+
+func BenchmarkLayoutInsert(b *testing.B) {
+	keys := benchKeys(100000)
+	b.ResetTimer()
+
+	var d *Cedar
+	for i := 0; i < b.N; i++ {
+		d = New()
+		for j, key := range keys {
+			d.Insert(key, j)
+		}
+	}
+	reportLayout(b, d, len(keys))
+}
+
+func BenchmarkLayoutGet(b *testing.B) {
+	keys := benchKeys(100000)
+	d := New()
+	for j, key := range keys {
+		d.Insert(key, j)
+	}
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		d.Get(keys[i%len(keys)])
+	}
+	reportLayout(b, d, len(keys))
+}
+
+func sortedKeys(n int) [][]byte {
+	keys := benchKeys(n)
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i], keys[j]) < 0
+	})
+	return keys
+}
+
+// buildSizes are the numbers of the keys of the Build benchmarks,
+// compare the Build and the Insert of the same keys, which are inserted
+// in their order and in the sorted order:
+//
+//	go test -run - -bench Build
+var buildSizes = []int{100000, 2000000}
+
+func BenchmarkBuild(b *testing.B) {
+	for _, n := range buildSizes {
+		keys := sortedKeys(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				New().Build(keys, nil)
+			}
+		})
+	}
+}
+
+func BenchmarkBuildInsert(b *testing.B) {
+	for _, n := range buildSizes {
+		keys := benchKeys(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d := New()
+				for j, key := range keys {
+					d.Insert(key, j)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkBuildInsertSorted(b *testing.B) {
+	for _, n := range buildSizes {
+		keys := sortedKeys(n)
+		b.Run(strconv.Itoa(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				d := New()
+				for j, key := range keys {
+					d.Insert(key, j)
+				}
+			}
+		})
+	}
+}
@@ -28,21 +28,25 @@ func TestLoadData(t *testing.T) {
 	for i, word := range words {
 		err := cd.Insert([]byte(word), i)
 		tt.Nil(t, err)
+		tt.Nil(t, cd.Validate())
 	}
 
 	// update the words
 	for i, word := range words {
 		err := cd.Delete([]byte(word))
 		tt.Nil(t, err)
+		tt.Nil(t, cd.Validate())
 
 		err = cd.Update([]byte(word), i)
 		tt.Nil(t, err)
+		tt.Nil(t, cd.Validate())
 	}
 
 	// delete not used word
 	for i := 10; i < 15; i++ {
 		err := cd.Delete([]byte(words[i]))
 		tt.Nil(t, err)
+		tt.Nil(t, cd.Validate())
 	}
 }
 
@@ -92,3 +96,87 @@ func TestPrefixPredict(t *testing.T) {
 		tt.Equal(t, values[i], v)
 	}
 }
+
+func TestKey(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range words {
+			err := d.Insert([]byte(word), i)
+			tt.Nil(t, err)
+			tt.Nil(t, d.Validate())
+		}
+
+		for _, word := range words {
+			to, err := d.Jump([]byte(word), 0)
+			tt.Nil(t, err)
+			key, err := d.Key(to)
+			tt.Nil(t, err)
+			tt.Equal(t, word, string(key))
+		}
+
+		keys := []string{"太阳系", "太阳系土星", "太阳系地球", "太阳系天王星"}
+		ids := d.PrefixPredict([]byte("太阳系"), 4)
+		tt.Equal(t, len(keys), len(ids))
+		for i, id := range ids {
+			key, err := d.Key(id)
+			tt.Nil(t, err)
+			tt.Equal(t, keys[i], string(key))
+		}
+
+		ids = d.PrefixMatch([]byte("abcdef"))
+		tt.Equal(t, 5, len(ids))
+		key, err := d.Key(ids[4])
+		tt.Nil(t, err)
+		tt.Equal(t, "abcdef", string(key))
+
+		_, err = d.Key(0)
+		tt.Equal(t, ErrNoKey, err)
+		_, err = d.Key(len(d.array))
+		tt.Equal(t, ErrNoKey, err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		err := d.Update([]byte("a"), 1)
+		tt.Nil(t, err)
+		err = d.Update([]byte("a"), 2)
+		tt.Nil(t, err)
+		err = d.Update([]byte("ab"), 3)
+		tt.Nil(t, err)
+
+		val, err := d.Get([]byte("a"))
+		tt.Nil(t, err)
+		tt.Equal(t, 3, val)
+
+		// the negative value decreases it
+		err = d.Update([]byte("a"), -1)
+		tt.Nil(t, err)
+		val, err = d.Get([]byte("a"))
+		tt.Nil(t, err)
+		tt.Equal(t, 2, val)
+
+		// the sum is limited like Insert, the failed Update changes nothing
+		err = d.Update([]byte("a"), -3)
+		tt.Equal(t, ErrInvalidVal, err)
+		err = d.Update([]byte("b"), -1)
+		tt.Equal(t, ErrInvalidVal, err)
+		err = d.Update([]byte("b"), ValLimit)
+		tt.Equal(t, ErrInvalidVal, err)
+		err = d.Update([]byte("a"), ValLimit-2)
+		tt.Equal(t, ErrInvalidVal, err)
+		err = d.Update([]byte("a"), -2)
+		tt.Nil(t, err)
+		err = d.Update([]byte("a"), ValLimit-1)
+		tt.Nil(t, err)
+
+		val, err = d.Get([]byte("a"))
+		tt.Nil(t, err)
+		tt.Equal(t, ValLimit-1, val)
+		_, err = d.Get([]byte("b"))
+		tt.Equal(t, ErrNoKey, err)
+		tt.Equal(t, 2, d.Len())
+		tt.Nil(t, d.Validate())
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+// Compact rebuild the trie densely after the deletions, the empty blocks are
+// dropped and the memory is trimmed to the used size.
+//
+// It returns the mapping of the node ids, ids[old] is the new id of the node
+// for the callers that cached the ids from Jump, PrefixMatch or PrefixPredict,
+// and it is -1 if the old id is not a node.
+func (cd *Cedar) Compact() (ids []int) {
+	nc := NewWithOptions(WithReduced(cd.Reduced),
+		WithOrdered(cd.ordered), WithMaxTrial(int(cd.maxTrial)))
+
+	// grow once for the nodes and the free slots left by the placing
+	nodes := cd.Stats().Nodes
+	nc.reserve(nc.size + nodes + nodes/8)
+
+	ids = make([]int, cd.size)
+	for i := range ids {
+		ids[i] = -1
+	}
+	ids[0] = 0
+	cd.compact(nc, 0, 0, ids)
+
+	nc = nc.clone()
+	nc.count = cd.count
+	nc.gen = cd.gen + 1
+	*cd = *nc
+
+	return ids
+}
+
+// compact copy the children of `from` to the node `nfrom` of the new trie
+func (cd *Cedar) compact(nc *Cedar, from, nfrom int, ids []int) {
+	// the leaf of the reduced trie holds the value
+	if cd.Reduced && cd.array[from].baseV >= 0 {
+		nc.array[nfrom].baseV = cd.array[from].baseV
+		return
+	}
+
+	var buf [257]byte
+	labels := buf[:0]
+
+	// the root is its own terminal node, it has no value
+	base := cd.array[from].base(cd.Reduced)
+	if from != 0 && base >= 0 && int(cd.array[base].check) == from {
+		labels = append(labels, 0)
+	}
+	cd.eachChild(from, func(to int, label byte) bool {
+		labels = append(labels, label)
+		return true
+	})
+
+	if len(labels) == 0 {
+		return
+	}
+
+	// the reduced node with only the terminal node becomes a leaf
+	if cd.Reduced && len(labels) == 1 && labels[0] == 0 {
+		nc.array[nfrom].baseV = cd.array[base].baseV
+		ids[base] = nfrom
+		return
+	}
+
+	nbase := nc.placeChildren(nfrom, labels)
+	for _, label := range labels {
+		to, nto := base^int(label), nbase^int(label)
+		ids[to] = nto
+
+		if label == 0 {
+			nc.array[nto].baseV = cd.array[to].baseV
+		} else {
+			cd.compact(nc, to, nto, ids)
+		}
+	}
+}
+
+// placeChildren place all the `labels` as the children of `from` at once,
+// and return the base of `from`.
+func (cd *Cedar) placeChildren(from int, labels []byte) int {
+	// the root stays at the base 0 as New
+	base := 0
+	if from != 0 {
+		if len(labels) == 1 {
+			base = cd.findPlace()
+		} else {
+			base = cd.findPlaces(labels)
+		}
+		base ^= int(labels[0])
+
+		if !cd.Reduced {
+			cd.array[from].baseV = nodeInt(base)
+		} else {
+			cd.array[from].baseV = nodeInt(-base - 1)
+		}
+	}
+
+	// the root is its own terminal node, its sibling chain holds the children
+	head := &cd.nInfos[from].child
+	if from == 0 {
+		head = &cd.nInfos[from].sibling
+	}
+	*head = labels[0]
+
+	for i, label := range labels {
+		to := cd.popENode(base, from, label)
+		if i < len(labels)-1 {
+			cd.nInfos[to].sibling = labels[i+1]
+		}
+	}
+
+	return base
+}
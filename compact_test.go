@@ -0,0 +1,86 @@
+package cedar
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestCompact(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i := 0; i < 20000; i++ {
+			err := d.Insert([]byte("key"+strconv.Itoa(i)), i)
+			tt.Nil(t, err)
+		}
+		for i, word := range words {
+			err := d.Insert([]byte(word), i)
+			tt.Nil(t, err)
+		}
+		for i := 0; i < 20000; i++ {
+			if i%10 != 0 {
+				err := d.Delete([]byte("key" + strconv.Itoa(i)))
+				tt.Nil(t, err)
+			}
+		}
+		// the reduced node left with only the terminal node
+		err := d.Delete([]byte("abcdef"))
+		tt.Nil(t, err)
+
+		jumped, err := d.Jump([]byte("太阳"), 0)
+		tt.Nil(t, err)
+		matched := d.PrefixMatch([]byte("this is a cedar."))
+		predicted := d.PrefixPredict([]byte("ab"))
+		before := d.Stats()
+
+		ids := d.Compact()
+		tt.Nil(t, d.Validate())
+		after := d.Stats()
+		tt.Equal(t, before.Keys, after.Keys)
+		tt.True(t, after.Size < before.Size/4)
+		tt.Equal(t, after.Size, after.Capacity)
+
+		for i := 0; i < 20000; i++ {
+			val, err := d.Get([]byte("key" + strconv.Itoa(i)))
+			if i%10 != 0 {
+				tt.NotNil(t, err)
+			} else {
+				tt.Nil(t, err)
+				tt.Equal(t, i, val)
+			}
+		}
+
+		// the cached ids are mapped to the new ones
+		to, err := d.Jump([]byte("太阳"), 0)
+		tt.Nil(t, err)
+		tt.Equal(t, to, ids[jumped])
+		for i, id := range d.PrefixMatch([]byte("this is a cedar.")) {
+			tt.Equal(t, id, ids[matched[i]])
+		}
+		for i, id := range predicted {
+			key, err := d.Key(ids[id])
+			tt.Nil(t, err)
+			tt.Equal(t, []string{"ab", "abc", "abcd"}[i], string(key))
+			val, err := d.Value(ids[id])
+			tt.Nil(t, err)
+			tt.Equal(t, i+2, val)
+		}
+		tt.Equal(t, -1, ids[len(ids)-1])
+
+		// the compacted trie is still updatable
+		for i := 0; i < 1000; i++ {
+			err = d.Insert([]byte("new"+strconv.Itoa(i)), i)
+			tt.Nil(t, err)
+		}
+		err = d.Insert([]byte("abcdef"), 5)
+		tt.Nil(t, err)
+		for i, word := range words {
+			val, err := d.Get([]byte(word))
+			tt.Nil(t, err)
+			tt.Equal(t, i, val)
+		}
+		tt.Nil(t, d.Validate())
+		tt.Equal(t, 2000+len(words)+1000, d.Len())
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import "math"
+
+// prefixEnds call fn with the end offset and the value of every key
+// which is a prefix of text[i:] and ends on the boundary of the runes,
+// or with the next boundary and NoVal if there is none.
+func (cd *Cedar) prefixEnds(text []byte, i int, fn func(end, val int)) {
+	found := false
+	for from, j := 0, i; j < len(text); j++ {
+		to, ok := cd.childOf(from, text[j])
+		if !ok {
+			break
+		}
+		from = to
+
+		if val, err := cd.Value(to); err == nil && boundary(text, j+1) {
+			fn(j+1, val)
+			found = true
+		}
+	}
+
+	if !found {
+		fn(nextBoundary(text, i), NoVal)
+	}
+}
+
+// DAG return the directed acyclic graph of the words in the text, it maps
+// every start offset of the runes to the end offsets of the keys starting
+// there in the ascending order; the next boundary is used if no key starts
+// there, so there is always a path through the text, an invalid byte
+// which is not a rune start is kept with the rune before it.
+func (cd *Cedar) DAG(text []byte) map[int][]int {
+	dag := make(map[int][]int)
+	for i := 0; i < len(text); i++ {
+		if !boundary(text, i) {
+			continue
+		}
+
+		cd.prefixEnds(text, i, func(end, val int) {
+			dag[i] = append(dag[i], end)
+		})
+	}
+
+	return dag
+}
+
+// Weight is how Viterbi reads the values of the keys
+type Weight int
+
+const (
+	// LogFreq the value is the log frequency of the word scaled by LogScale,
+	// round(ln(freq) * LogScale), it is the default.
+	LogFreq Weight = iota
+	// RawFreq the value is the frequency (count) of the word,
+	// the value 0 is counted as 1.
+	RawFreq
+)
+
+// LogScale is the scale of the log frequencies of LogFreq,
+// so the fractions of the logs are kept in the int values.
+const LogScale = 1000
+
+// SetWeight set how Viterbi reads the values, the default is LogFreq
+func (s *Segmenter) SetWeight(w Weight) {
+	s.weight = w
+	s.totalGen = 0
+}
+
+// logFreq return the log frequency of the value,
+// the unknown rune of NoVal has the frequency 1.
+func (s *Segmenter) logFreq(val int) float64 {
+	switch {
+	case val == NoVal:
+		return 0
+	case s.weight == RawFreq:
+		return math.Log(float64(max(val, 1)))
+	}
+	return float64(val) / LogScale
+}
+
+// logTotal return the log of the sum of the frequencies of the dictionary,
+// it is computed on the first use and after the dictionary is modified.
+func (s *Segmenter) logTotal() float64 {
+	if s.totalGen == s.cd.gen+1 {
+		return s.total
+	}
+
+	// the sum is shifted by the max log frequency, so exp does not overflow
+	top := 0.0
+	s.cd.Walk(func(key []byte, val int) bool {
+		top = max(top, s.logFreq(val))
+		return true
+	})
+	sum := 0.0
+	s.cd.Walk(func(key []byte, val int) bool {
+		sum += math.Exp(s.logFreq(val) - top)
+		return true
+	})
+
+	s.total = top + math.Log(max(sum, 1))
+	s.totalGen = s.cd.gen + 1
+	return s.total
+}
+
+// Viterbi cut the text by the path of the max probability on the DAG,
+// the probability of a word is freq / total where total is the sum of
+// the frequencies of the dictionary, the frequency of the unknown rune is 1.
+// The values are the log frequencies by default, see SetWeight.
+func (s *Segmenter) Viterbi(text []byte) []Token {
+	logTotal := s.logTotal()
+
+	// route[i] is the best log probability of text[i:], by the token at i
+	route := make([]float64, len(text)+1)
+	best := make([]Token, len(text))
+	for i := len(text) - 1; i >= 0; i-- {
+		if !boundary(text, i) {
+			continue
+		}
+
+		route[i] = math.Inf(-1)
+		s.cd.prefixEnds(text, i, func(end, val int) {
+			// the longer word wins the tie
+			p := s.logFreq(val) - logTotal + route[end]
+			if p >= route[i] {
+				route[i], best[i] = p, Token{Start: i, End: end, Value: val}
+			}
+		})
+	}
+
+	var toks []Token
+	for i := 0; i < len(text); i = best[i].End {
+		toks = append(toks, best[i])
+	}
+	return toks
+}
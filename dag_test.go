@@ -0,0 +1,85 @@
+package cedar
+
+import (
+	"math"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestDAG(t *testing.T) {
+	freqs := map[string]int{
+		"研究": 50, "研究生": 10, "生命": 80, "命": 5, "起源": 40, "\xe8\xb5": 1,
+	}
+
+	for _, reduced := range []bool{true, false} {
+		for _, w := range []Weight{LogFreq, RawFreq} {
+			// the value of the weight for the frequency
+			value := func(freq int) int {
+				if w == RawFreq {
+					return freq
+				}
+				return int(math.Round(math.Log(float64(freq)) * LogScale))
+			}
+
+			d := New(reduced)
+			for word, freq := range freqs {
+				err := d.InsertString(word, value(freq))
+				tt.Nil(t, err)
+			}
+
+			text := "研究生命起源x"
+			tt.Equal(t, map[int][]int{
+				0: {6, 9}, 3: {6}, 6: {12}, 9: {12}, 12: {18}, 15: {18}, 18: {19},
+			}, d.DAG([]byte(text)))
+			tt.Equal(t, 0, len(d.DAG(nil)))
+
+			s := NewSegmenter(d)
+			s.SetWeight(w)
+			toks := s.Viterbi([]byte(text))
+			tt.Equal(t, []string{"研究", "生命", "起源", "x"}, tokenStrings(text, toks))
+			tt.Equal(t, Token{0, 6, value(50)}, toks[0])
+			tt.Equal(t, Token{18, 19, NoVal}, toks[3])
+
+			// 研究生 is more likely than 研究 with the unknown 生
+			text = "研究生"
+			tt.Equal(t, []string{"研究生"}, tokenStrings(text, s.Viterbi([]byte(text))))
+
+			// the total is computed again after the dictionary is modified
+			tt.True(t, math.Abs(s.total-math.Log(186)) < 1e-3)
+			err := d.InsertString("究生", value(1000))
+			tt.Nil(t, err)
+			text = "研究生命"
+			tt.Equal(t, []string{"研究", "生命"}, tokenStrings(text, s.Viterbi([]byte(text))))
+			tt.True(t, math.Abs(s.total-math.Log(1186)) < 1e-3)
+			tt.Equal(t, 0, len(s.Viterbi(nil)))
+		}
+	}
+
+	// the large log frequencies do not overflow the total
+	d := New()
+	err := d.InsertString("a", 1000*LogScale)
+	tt.Nil(t, err)
+	err = d.InsertString("b", 999*LogScale)
+	tt.Nil(t, err)
+	s := NewSegmenter(d)
+	tt.Equal(t, 2, len(s.Viterbi([]byte("ab"))))
+	tt.True(t, math.Abs(s.total-1000-math.Log1p(math.Exp(-1))) < 1e-9)
+
+	// the invalid and the truncated runes are kept with the rune before them
+	for text, want := range map[string][]string{
+		"a\x80b":            {"a\x80", "b"},
+		"\x80a":             {"\x80", "a"},
+		"a\xe5\xa4":         {"a", "\xe5\xa4"},
+		"b\xe5\xa4\x80\x80": {"b", "\xe5\xa4\x80\x80"},
+	} {
+		tt.Equal(t, want, tokenStrings(text, s.Viterbi([]byte(text))))
+		tt.Equal(t, want, tokenStrings(text, s.Forward([]byte(text))))
+		tt.Equal(t, want, tokenStrings(text, s.Backward([]byte(text))))
+
+		dag := d.DAG([]byte(text))
+		for i := 0; i < len(text); i = dag[i][0] {
+			tt.True(t, len(dag[i]) > 0)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// Filter find the sensitive words of the dictionary in the text by the
+// Aho-Corasick automaton, the noise runes of the skip set are ignored,
+// so the word split by them, such as "f*u_c k", is still found.
+//
+// The Filter is not safe for concurrent use, because the automaton is
+// rebuilt when the dictionary is modified.
+type Filter struct {
+	ac   *AhoCorasick
+	skip func(r rune) bool
+}
+
+// NewFilter return the Filter of the dictionary `cd`,
+// the runes in `skip` are the noise between the bytes of the words.
+func NewFilter(cd *Cedar, skip string) *Filter {
+	set := make(map[rune]bool)
+	for _, r := range skip {
+		set[r] = true
+	}
+
+	return NewFilterFunc(cd, func(r rune) bool {
+		return set[r]
+	})
+}
+
+// NewFilterFunc return the Filter of the dictionary `cd`,
+// the runes reported by `skip` are the noise, such as unicode.IsSpace.
+func NewFilterFunc(cd *Cedar, skip func(r rune) bool) *Filter {
+	return &Filter{ac: NewAhoCorasick(cd), skip: skip}
+}
+
+// Find return the non-overlapping hits in the text by the leftmost-longest
+// matching, the span of the hit is of the original text, which includes
+// the noise runes inside the word.
+func (f *Filter) Find(text []byte) (ms []Match) {
+	t := f.ac.links()
+
+	// the offsets of the last bytes fed to the automaton and the ends of
+	// their runes, by the count `j` of the fed bytes; the candidate and the
+	// bytes after it are never longer than the longest key plus one
+	pos := make([]int, t.depth+1)
+	ends := make([]int, len(pos))
+
+	state, has, cand, j := 0, false, Match{}, 0
+	for i, stop := 0, 0; ; {
+		// text[i:stop] is the rest of the rune being fed, skip the noise runes
+		for i == stop && i < len(text) {
+			r, size := utf8.DecodeRune(text[i:])
+			if stop = i + size; f.skip(r) {
+				i = stop
+			}
+		}
+
+		end := i == len(text)
+		if !end {
+			pos[j%len(pos)], ends[j%len(pos)] = i, stop
+			state = t.step(state, text[i])
+			i++
+			j++
+			if o := t.outs[state]; o > 0 {
+				m := Match{Start: j - t.lens[o], End: j, ID: o}
+				m.Value, _ = t.cd.Value(o)
+				if !has || better(m, cand, MatchLeftmostLongest) {
+					cand, has = m, true
+				}
+			}
+		}
+
+		// the same as leftmost, restart after the last byte of the candidate
+		if has && (end || j-t.lens[state] > cand.Start ||
+			j-t.lens[state] == cand.Start && t.final(state, cand, MatchLeftmostLongest)) {
+			last := (cand.End - 1) % len(pos)
+			i, stop, j, state, has = pos[last]+1, ends[last], cand.End, 0, false
+			cand.Start, cand.End = pos[cand.Start%len(pos)], i
+			ms = append(ms, cand)
+			continue
+		}
+
+		if end {
+			return ms
+		}
+	}
+}
+
+// Contains report whether the text has any hit
+func (f *Filter) Contains(text []byte) bool {
+	t := f.ac.links()
+
+	state := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+		if f.skip(r) {
+			i += size
+			continue
+		}
+
+		for ; size > 0; size-- {
+			state = t.step(state, text[i])
+			if t.outs[state] > 0 {
+				return true
+			}
+			i++
+		}
+	}
+	return false
+}
+
+// FindString is Find on string
+func (f *Filter) FindString(s string) []Match {
+	return f.Find(bytesOf(s))
+}
+
+// ContainsString is Contains on string
+func (f *Filter) ContainsString(s string) bool {
+	return f.Contains(bytesOf(s))
+}
+
+// Mask return a copy of the text with every rune of the hits
+// replaced by the `mask` rune
+func (f *Filter) Mask(text []byte, mask rune) []byte {
+	if buf, ok := f.mask(text, mask); ok {
+		return buf
+	}
+	return slices.Clone(text)
+}
+
+// MaskString return `s` with every rune of the hits replaced by the `mask` rune
+func (f *Filter) MaskString(s string, mask rune) string {
+	if buf, ok := f.mask(bytesOf(s), mask); ok {
+		return string(buf)
+	}
+	return s
+}
+
+// mask the hits of the text, it is false if there is no hit
+func (f *Filter) mask(text []byte, mask rune) ([]byte, bool) {
+	ms := f.Find(text)
+	if len(ms) == 0 {
+		return nil, false
+	}
+
+	buf, last := make([]byte, 0, len(text)), 0
+	for _, m := range ms {
+		buf = append(buf, text[last:m.Start]...)
+		for n := utf8.RuneCount(text[m.Start:m.End]); n > 0; n-- {
+			buf = utf8.AppendRune(buf, mask)
+		}
+		last = m.End
+	}
+	return append(buf, text[last:]...), true
+}
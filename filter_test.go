@@ -0,0 +1,60 @@
+package cedar
+
+import (
+	"testing"
+	"unicode"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestFilter(t *testing.T) {
+	d := New()
+	for i, word := range []string{"fuck", "shit", "坏人", "坏蛋", "坏"} {
+		err := d.InsertString(word, i)
+		tt.Nil(t, err)
+	}
+
+	f := NewFilter(d, "*_ -·")
+	text := "oh f*u_c k, 坏·人 and *shit*!"
+	ms := f.FindString(text)
+	tt.Equal(t, 3, len(ms))
+	tt.Equal(t, "f*u_c k", text[ms[0].Start:ms[0].End])
+	tt.Equal(t, 0, ms[0].Value)
+	tt.Equal(t, "坏·人", text[ms[1].Start:ms[1].End])
+	tt.Equal(t, 2, ms[1].Value)
+	// the noise around the word is not in the span
+	tt.Equal(t, "shit", text[ms[2].Start:ms[2].End])
+
+	tt.Equal(t, "oh *******, *** and ******!", f.MaskString(text, '*'))
+	tt.Equal(t, "oh ＃＃＃＃＃＃＃, ＃＃＃ and *＃＃＃＃*!", f.MaskString(text, '＃'))
+	tt.Equal(t, []byte("xxx"), f.Mask([]byte("坏_人"), 'x'))
+
+	tt.True(t, f.ContainsString("s h i t"))
+	tt.False(t, f.ContainsString("s h i p"))
+	tt.False(t, f.Contains(nil))
+	tt.Equal(t, "clean", f.MaskString("clean", '*'))
+	tt.Equal(t, 0, len(f.FindString("* _ *")))
+
+	// without the skip set, the noise breaks the word
+	tt.Equal(t, 2, len(NewFilter(d, "").FindString(text)))
+
+	f = NewFilterFunc(d, func(r rune) bool {
+		return unicode.IsSpace(r) || unicode.IsPunct(r)
+	})
+	tt.Equal(t, "*******!? ****", f.MaskString("f-u-c-k!? 坏, 人", '*'))
+	tt.Equal(t, "F-u-c-k *!", f.MaskString("F-u-c-k 坏!", '*'))
+
+	// the scan restarts inside the rune of the hit, the rest is not noise
+	b := New()
+	b.InsertString("\xe4\xbd", 1)
+	b.InsertString("\xa0", 2)
+	ms = NewFilter(b, "*\uFFFD").FindString("你*")
+	tt.Equal(t, 2, len(ms))
+	tt.Equal(t, Match{Start: 2, End: 3, Value: 2, ID: ms[1].ID}, ms[1])
+	tt.Equal(t, 0, len(NewFilter(b, "*\uFFFD").FindString("\xa0")))
+
+	// the automaton is rebuilt after the dictionary is modified
+	err := d.DeleteString("fuck")
+	tt.Nil(t, err)
+	tt.False(t, f.ContainsString("f.u.c.k"))
+}
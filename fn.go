@@ -6,6 +6,7 @@ package cedar
 
 import (
 	"errors"
+	"unicode/utf8"
 )
 
 var (
@@ -17,6 +18,18 @@ var (
 	ErrInvalidKey = errors.New("cedar: invalid key")
 	// ErrInvalidVal invalid value error
 	ErrInvalidVal = errors.New("cedar: invalid val")
+	// ErrInvalidData invalid serialized data error
+	ErrInvalidData = errors.New("cedar: invalid data")
+	// ErrVersion unsupported serialized data version error
+	ErrVersion = errors.New("cedar: unsupported data version")
+	// ErrNotSorted keys not sorted error
+	ErrNotSorted = errors.New("cedar: keys not sorted")
+	// ErrDuplicateKey duplicate key error
+	ErrDuplicateKey = errors.New("cedar: duplicate key")
+	// ErrNotEmpty trie not empty error
+	ErrNotEmpty = errors.New("cedar: trie not empty")
+	// ErrCorrupt corrupt trie error, returned by Validate
+	ErrCorrupt = errors.New("cedar: corrupt trie")
 )
 
 func isReduced(reduced ...bool) bool {
@@ -27,17 +40,21 @@ func isReduced(reduced ...bool) bool {
 	return true
 }
 
-func (cd *Cedar) get(key []byte, from, pos int) *int {
-	to := cd.getNode(key, from, pos)
+func (cd *Cedar) get(key []byte, from, pos int) *nodeInt {
+	to, added := cd.getNode(key, from, pos)
+	if added {
+		cd.count++
+	}
 	return &cd.array[to].baseV
 }
 
-// getNode get the follow node by key, split by update()
-func (cd *Cedar) getNode(key []byte, from, pos int) int {
+// getNode get the follow node by key, split by update(),
+// `added` reports whether the value node of the key is new.
+func (cd *Cedar) getNode(key []byte, from, pos int) (to int, added bool) {
 	for ; pos < len(key); pos++ {
 		if cd.Reduced {
 			value := cd.array[from].baseV
-			if value >= 0 && value != ValLimit {
+			if value >= 0 && int(value) != ValLimit {
 				to := cd.follow(from, 0)
 				cd.array[to].baseV = value
 			}
@@ -46,12 +63,20 @@ func (cd *Cedar) getNode(key []byte, from, pos int) int {
 		from = cd.follow(from, key[pos])
 	}
 
-	to := from
+	to = from
 	if cd.array[from].baseV < 0 || !cd.Reduced {
+		if !cd.Reduced {
+			base := cd.array[from].base(cd.Reduced)
+			added = base < 0 || int(cd.array[base].check) != from
+		}
 		to = cd.follow(from, 0)
 	}
 
-	return to
+	// the new value node of the reduced trie is not set
+	if cd.Reduced {
+		added = int(cd.array[to].baseV) == ValLimit
+	}
+	return
 }
 
 // Jump jump a node `from` to another node by following the `path`, split by find()
@@ -67,7 +92,7 @@ func (cd *Cedar) Jump(key []byte, from int) (to int, err error) {
 		}
 
 		to = cd.array[from].base(cd.Reduced) ^ int(k)
-		if cd.array[to].check != from {
+		if int(cd.array[to].check) != from {
 			return from, ErrNoKey
 		}
 		from = to
@@ -82,7 +107,7 @@ func (cd *Cedar) Find(key []byte, from int) (int, error) {
 	if cd.Reduced {
 		if cd.array[to].baseV >= 0 {
 			if err == nil && to != 0 {
-				return cd.array[to].baseV, nil
+				return int(cd.array[to].baseV), nil
 			}
 			return 0, ErrNoKey
 		}
@@ -99,28 +124,28 @@ func (cd *Cedar) Find(key []byte, from int) (int, error) {
 		return 0, ErrNoKey
 	}
 	n := cd.array[base]
-	if n.check != to {
+	if int(n.check) != to {
 		return 0, ErrNoKey
 	}
-	return n.baseV, nil
+	return int(n.baseV), nil
 }
 
 // Value get the path value
 func (cd *Cedar) Value(path int) (val int, err error) {
-	val = cd.array[path].baseV
+	val = int(cd.array[path].baseV)
 	if val >= 0 && cd.Reduced {
 		return val, nil
 	}
 
 	to := cd.array[path].base(cd.Reduced)
 	if to >= 0 && to < len(cd.array) &&
-		cd.array[to].check == path && cd.array[to].baseV >= 0 {
-		return cd.array[to].baseV, nil
+		int(cd.array[to].check) == path && cd.array[to].baseV >= 0 {
+		return int(cd.array[to].baseV), nil
 	}
 
 	// For non-reduced: if this IS a terminal node (0-child), baseV is the stored value
 	if !cd.Reduced && val >= 0 {
-		from := cd.array[path].check
+		from := int(cd.array[path].check)
 		if from >= 0 {
 			base := cd.array[from].base(cd.Reduced)
 			if byte(path^base) == 0 {
@@ -139,21 +164,29 @@ func (cd *Cedar) Insert(key []byte, val int) error {
 	}
 
 	p := cd.get(key, 0, 0)
-	*p = val
+	*p = nodeInt(val)
+	cd.gen++
 
 	return nil
 }
 
-// Update the key for the value, it is public interface that works on []byte
+// Update the key for the value, it is public interface that works on []byte,
+// the value is added to the current value of the key or inserted if the key
+// is not there, the value could be negative to decrease it, it is ErrInvalidVal
+// if the sum is negative or out of ValLimit.
 func (cd *Cedar) Update(key []byte, value int) error {
-	p := cd.get(key, 0, 0)
-
-	if *p == ValLimit && cd.Reduced {
-		*p = value
-		return nil
+	cur, err := cd.Get(key)
+	if err != nil {
+		cur = 0
+	}
+	if cur+value < 0 || value >= ValLimit-cur {
+		return ErrInvalidVal
 	}
 
-	*p += value
+	p := cd.get(key, 0, 0)
+	*p = nodeInt(cur + value)
+	cd.gen++
+
 	return nil
 }
 
@@ -164,10 +197,16 @@ func (cd *Cedar) Delete(key []byte) error {
 	if err != nil {
 		return ErrNoKey
 	}
+	// the key is only a prefix of the others
+	if _, err := cd.Value(to); err != nil {
+		return ErrNoKey
+	}
+	cd.gen++
+	cd.count--
 
 	if cd.array[to].baseV < 0 && cd.Reduced {
 		base := cd.array[to].base(cd.Reduced)
-		if cd.array[base].check == to {
+		if int(cd.array[base].check) == to {
 			to = base
 		}
 	}
@@ -178,7 +217,7 @@ func (cd *Cedar) Delete(key []byte) error {
 
 	from := to
 	for to > 0 {
-		from = cd.array[to].check
+		from = int(cd.array[to].check)
 		base := cd.array[from].base(cd.Reduced)
 		label := byte(to ^ base)
 
@@ -212,6 +251,41 @@ func (cd *Cedar) Get(key []byte) (value int, err error) {
 	return cd.Value(to)
 }
 
+// Key restore the key of the node `id` by walking the `check` to the root,
+// the id could be the one returned by Jump, PrefixMatch or PrefixPredict.
+func (cd *Cedar) Key(id int) ([]byte, error) {
+	if id <= 0 || id >= cd.size || cd.array[id].check < 0 {
+		return nil, ErrNoKey
+	}
+
+	var key []byte
+	for to := id; to > 0; {
+		from := int(cd.array[to].check)
+		if from < 0 || from >= cd.size || len(key) >= cd.size {
+			return nil, ErrNoKey
+		}
+
+		base := cd.array[from].base(cd.Reduced)
+		if base < 0 || (to^base)>>8 != 0 {
+			return nil, ErrNoKey
+		}
+
+		// only the id itself could be the terminal node of label 0
+		label := byte(to ^ base)
+		if label != 0 {
+			key = append(key, label)
+		} else if to != id {
+			return nil, ErrNoKey
+		}
+		to = from
+	}
+
+	for i, j := 0, len(key)-1; i < j; i, j = i+1, j-1 {
+		key[i], key[j] = key[j], key[i]
+	}
+	return key, nil
+}
+
 // ExactMatch to check if `key` is in the dictionary.
 func (cd *Cedar) ExactMatch(key []byte) (int, bool) {
 	from := 0
@@ -225,6 +299,12 @@ func (cd *Cedar) ExactMatch(key []byte) (int, bool) {
 // PrefixMatch return the collection of the common prefix
 // in the dictionary with the `key`
 func (cd *Cedar) PrefixMatch(key []byte, n ...int) (ids []int) {
+	return cd.prefixMatch(key, false, n...)
+}
+
+// prefixMatch is PrefixMatch, only the prefix ending on
+// the boundary of the UTF-8 runes is matched if `runes` is true.
+func (cd *Cedar) prefixMatch(key []byte, runes bool, n ...int) (ids []int) {
 	num := 0
 	if len(n) > 0 {
 		num = n[0]
@@ -235,6 +315,11 @@ func (cd *Cedar) PrefixMatch(key []byte, n ...int) (ids []int) {
 		if err != nil {
 			break
 		}
+		from = to
+
+		if runes && i+1 < len(key) && !utf8.RuneStart(key[i+1]) {
+			continue
+		}
 
 		_, err = cd.Value(to)
 		if err == nil {
@@ -244,8 +329,6 @@ func (cd *Cedar) PrefixMatch(key []byte, n ...int) (ids []int) {
 				return
 			}
 		}
-
-		from = to
 	}
 
 	return
@@ -297,7 +380,7 @@ func (cd *Cedar) next(from int, root int) (to int, err error) {
 
 	// traversing up until there is a sibling or it has reached the root.
 	for c == 0 && from != root && cd.array[from].check >= 0 {
-		from = cd.array[from].check
+		from = int(cd.array[from].check)
 		c = cd.nInfos[from].sibling
 	}
 
@@ -309,3 +392,46 @@ func (cd *Cedar) next(from int, root int) (to int, err error) {
 	from = cd.array[cd.array[from].check].base(cd.Reduced) ^ int(c)
 	return cd.begin(from)
 }
+
+// childOf return the child node of `from` by following the `label`,
+// the terminal label 0 is not a transition.
+func (cd *Cedar) childOf(from int, label byte) (int, bool) {
+	base := cd.array[from].base(cd.Reduced)
+	if label == 0 || base < 0 {
+		return 0, false
+	}
+
+	to := base ^ int(label)
+	if to >= len(cd.array) || int(cd.array[to].check) != from {
+		return 0, false
+	}
+	return to, true
+}
+
+// eachChild call fn with every child of `from` in the sibling order,
+// the terminal node is skipped; stop when fn return false.
+func (cd *Cedar) eachChild(from int, fn func(to int, label byte) bool) bool {
+	base := cd.array[from].base(cd.Reduced)
+	if base < 0 || base >= len(cd.array) {
+		return true
+	}
+
+	// the root is its own terminal node, so its children start from the sibling
+	c := cd.nInfos[from].child
+	if c == 0 && base != from && int(cd.array[base].check) != from {
+		return true
+	}
+
+	to := base ^ int(c)
+	for {
+		if c != 0 && !fn(to, c) {
+			return false
+		}
+
+		c = cd.nInfos[to].sibling
+		if c == 0 {
+			return true
+		}
+		to = base ^ int(c)
+	}
+}
@@ -0,0 +1,183 @@
+package cedar
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// fuzzAlphabet is small to make the keys share the prefixes,
+// and it has the multi-byte runes.
+const fuzzAlphabet = "abcd\xe5\xa4\xaa\xff"
+
+// maxFuzzScript is the max length of the fuzzed script, every operation
+// validates the trie and scans the map, so a script costs its square.
+const maxFuzzScript = 4 << 10
+
+// fuzzScript is runScript on the short scripts for the fuzzing
+func fuzzScript(t *testing.T, script []byte, reduced bool) {
+	if len(script) > maxFuzzScript {
+		return
+	}
+	runScript(t, script, reduced)
+}
+
+// runScript apply the operations of the script to the trie and
+// compare the results with a map.
+func runScript(t *testing.T, script []byte, reduced bool) {
+	d := New(reduced)
+	ref := make(map[string]int)
+
+	next := func() byte {
+		if len(script) == 0 {
+			return 0
+		}
+		b := script[0]
+		script = script[1:]
+		return b
+	}
+	nextKey := func() []byte {
+		key := make([]byte, next()%6+1)
+		for i := range key {
+			key[i] = fuzzAlphabet[int(next())%len(fuzzAlphabet)]
+		}
+		return key
+	}
+
+	for len(script) > 0 {
+		op, key := next()%6, nextKey()
+		switch op {
+		case 0:
+			val := int(next())
+			if err := d.Insert(key, val); err != nil {
+				t.Fatalf("Insert(%q): %v", key, err)
+			}
+			ref[string(key)] = val
+
+		case 1:
+			val := int(next())
+			if err := d.Update(key, val); err != nil {
+				t.Fatalf("Update(%q): %v", key, err)
+			}
+			ref[string(key)] += val
+
+		case 2:
+			_, ok := ref[string(key)]
+			if err := d.Delete(key); (err == nil) != ok {
+				t.Fatalf("Delete(%q): %v, exists %v", key, err, ok)
+			}
+			delete(ref, string(key))
+
+		case 3:
+			val, err := d.Get(key)
+			want, ok := ref[string(key)]
+			if (err == nil) != ok || ok && val != want {
+				t.Fatalf("Get(%q) = %d, %v, want %d, %v", key, val, err, want, ok)
+			}
+
+		case 4:
+			var want []int
+			for i := range key {
+				if val, ok := ref[string(key[:i+1])]; ok {
+					want = append(want, val)
+				}
+			}
+			fuzzValues(t, d, "PrefixMatch", key, d.PrefixMatch(key), want)
+
+		case 5:
+			var keys []string
+			for k := range ref {
+				if strings.HasPrefix(k, string(key)) {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+
+			ids := d.PrefixPredict(key)
+			want := make([]int, len(keys))
+			for i, k := range keys {
+				want[i] = ref[k]
+			}
+			fuzzValues(t, d, "PrefixPredict", key, ids, want)
+
+			for i, id := range ids {
+				if k, err := d.Key(id); i < len(keys) && (err != nil || string(k) != keys[i]) {
+					t.Fatalf("Key(%d) = %q, %v, want %q", id, k, err, keys[i])
+				}
+			}
+		}
+
+		if op <= 2 {
+			if err := d.Validate(); err != nil {
+				t.Fatalf("after %d(%q): %v", op, key, err)
+			}
+			if d.Len() != len(ref) {
+				t.Fatalf("Len() = %d, want %d", d.Len(), len(ref))
+			}
+		}
+	}
+
+	var keys []string
+	for k := range ref {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	i := 0
+	d.Walk(func(key []byte, val int) bool {
+		if i >= len(keys) || string(key) != keys[i] || val != ref[keys[i]] {
+			t.Fatalf("Walk %d: %q = %d, want %q", i, key, val, keys)
+		}
+		i++
+		return true
+	})
+	if i != len(keys) {
+		t.Fatalf("Walk %d keys, want %d", i, len(keys))
+	}
+}
+
+func fuzzValues(t *testing.T, d *Cedar, name string, key []byte, ids, want []int) {
+	vals := make([]int, 0, len(ids))
+	for _, id := range ids {
+		val, err := d.Value(id)
+		if err != nil {
+			t.Fatalf("%s(%q): Value(%d): %v", name, key, id, err)
+		}
+		vals = append(vals, val)
+	}
+
+	if len(vals) != len(want) {
+		t.Fatalf("%s(%q) = %v, want %v", name, key, vals, want)
+	}
+	for i := range vals {
+		if vals[i] != want[i] {
+			t.Fatalf("%s(%q) = %v, want %v", name, key, vals, want)
+		}
+	}
+}
+
+func fuzzSeeds(f *testing.F) {
+	f.Add([]byte{}, true)
+	f.Add(bytes.Repeat([]byte{0, 3, 1, 2, 3, 7}, 20), true)
+	f.Add(bytes.Repeat([]byte{0, 5, 1, 2, 3, 4, 5, 6, 9, 2, 2, 1, 2}, 30), false)
+	f.Add([]byte("\x00\x02abc\x01\x00\x03abcd\x02\x02\x02abc\x04\x05abcdef\x05\x00a"), true)
+	f.Add([]byte("\x00\x02abc\x01\x00\x03abcd\x02\x02\x02abc\x04\x05abcdef\x05\x00a"), false)
+}
+
+func FuzzCedar(f *testing.F) {
+	fuzzSeeds(f)
+	f.Fuzz(fuzzScript)
+}
+
+// TestDifferential run the long random scripts, so the relocation
+// of the blocks is covered without the fuzzing.
+func TestDifferential(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, reduced := range []bool{true, false} {
+		script := make([]byte, 1<<16)
+		r.Read(script)
+		runScript(t, script, reduced)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import "unicode/utf8"
+
+// FuzzyMatch is a key found by FuzzySearch within the edit distance
+type FuzzyMatch struct {
+	Key   []byte
+	Value int
+	Dist  int // the Levenshtein distance to the searched key
+}
+
+// fuzzy is the state of the fuzzy search, the rows of the Levenshtein
+// distance are computed along the path of the trie.
+type fuzzy struct {
+	cd      *Cedar
+	q       []rune
+	maxDist int
+	runes   bool
+
+	key []byte
+	ms  []FuzzyMatch
+}
+
+// FuzzySearch return the keys within the `maxDist` Levenshtein distance
+// of the `key`, the distance counts the edits of the bytes;
+// the matches are in the same order as Walk.
+func (cd *Cedar) FuzzySearch(key []byte, maxDist int) []FuzzyMatch {
+	q := make([]rune, len(key))
+	for i, c := range key {
+		q[i] = rune(c)
+	}
+	return cd.fuzzySearch(q, maxDist, false)
+}
+
+// FuzzySearchRune is FuzzySearch, but the distance counts the edits
+// of the UTF-8 runes, so a typo in a Chinese character is one edit.
+func (cd *Cedar) FuzzySearchRune(key []byte, maxDist int) []FuzzyMatch {
+	return cd.fuzzySearch([]rune(string(key)), maxDist, true)
+}
+
+func (cd *Cedar) fuzzySearch(q []rune, maxDist int, runes bool) []FuzzyMatch {
+	if maxDist < 0 {
+		return nil
+	}
+
+	f := &fuzzy{cd: cd, q: q, maxDist: maxDist, runes: runes}
+	row := make([]int, len(q)+1)
+	for i := range row {
+		row[i] = i
+	}
+	f.visit(0, row, 0)
+
+	return f.ms
+}
+
+// visit the node `from` with the distance `row` of the key to the node,
+// `pending` is the number of bytes of the incomplete rune at the end of the key.
+func (f *fuzzy) visit(from int, row []int, pending int) {
+	if val, err := f.cd.Value(from); from != 0 && err == nil {
+		// the incomplete rune at the end of the key is decoded byte by byte
+		last := row
+		for p := pending; p > 0; p-- {
+			last = f.step(last, utf8.RuneError)
+		}
+
+		if dist := last[len(last)-1]; dist <= f.maxDist {
+			key := append([]byte(nil), f.key...)
+			f.ms = append(f.ms, FuzzyMatch{Key: key, Value: val, Dist: dist})
+		}
+	}
+
+	f.cd.eachChild(from, func(to int, label byte) bool {
+		f.key = append(f.key, label)
+
+		next, p := row, pending+1
+		if !f.runes {
+			next, p = f.step(row, rune(label)), 0
+		}
+		// the rune is complete, or the invalid bytes are decoded one by one
+		for p > 0 && utf8.FullRune(f.key[len(f.key)-p:]) {
+			r, size := utf8.DecodeRune(f.key[len(f.key)-p:])
+			next = f.step(next, r)
+			p -= size
+		}
+
+		// the distance only grows down the subtree
+		if minRow(next) <= f.maxDist {
+			f.visit(to, next, p)
+		}
+
+		f.key = f.key[:len(f.key)-1]
+		return true
+	})
+}
+
+// step compute the next row of the distance by the symbol `c`
+func (f *fuzzy) step(row []int, c rune) []int {
+	next := make([]int, len(row))
+	next[0] = row[0] + 1
+	for j := 1; j < len(row); j++ {
+		cost := 1
+		if f.q[j-1] == c {
+			cost = 0
+		}
+		next[j] = min(row[j]+1, next[j-1]+1, row[j-1]+cost)
+	}
+	return next
+}
+
+func minRow(row []int) int {
+	m := row[0]
+	for _, d := range row[1:] {
+		m = min(m, d)
+	}
+	return m
+}
+
+// FuzzySearch return the keys within the `maxDist` edit distance of the `key`
+func (sc *SyncCedar) FuzzySearch(key []byte, maxDist int) []FuzzyMatch {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.FuzzySearch(key, maxDist)
+}
+
+// FuzzySearchRune return the keys within the `maxDist` edit distance
+// of the `key` counted by the runes
+func (sc *SyncCedar) FuzzySearchRune(key []byte, maxDist int) []FuzzyMatch {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.FuzzySearchRune(key, maxDist)
+}
+
+// FuzzySearch return the keys within the `maxDist` edit distance of the `key`
+func (s *Snapshot) FuzzySearch(key []byte, maxDist int) []FuzzyMatch {
+	return s.cd.FuzzySearch(key, maxDist)
+}
+
+// FuzzySearchRune return the keys within the `maxDist` edit distance
+// of the `key` counted by the runes
+func (s *Snapshot) FuzzySearchRune(key []byte, maxDist int) []FuzzyMatch {
+	return s.cd.FuzzySearchRune(key, maxDist)
+}
@@ -0,0 +1,91 @@
+package cedar
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func levenshtein[T byte | rune](a, b []T) int {
+	row := make([]int, len(b)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := range a {
+		prev := row[0]
+		row[0] = i + 1
+		for j := range b {
+			cost := 1
+			if a[i] == b[j] {
+				cost = 0
+			}
+			prev, row[j+1] = row[j+1], min(row[j+1]+1, row[j]+1, prev+cost)
+		}
+	}
+	return row[len(b)]
+}
+
+func TestFuzzySearch(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range words {
+			err := d.InsertString(word, i)
+			tt.Nil(t, err)
+		}
+
+		ms := d.FuzzySearch([]byte("abd"), 1)
+		tt.Equal(t, []FuzzyMatch{
+			{[]byte("ab"), 2, 1}, {[]byte("abc"), 3, 1}, {[]byte("abcd"), 4, 1},
+		}, ms)
+
+		// 金 to 木 is 3 edits of the bytes, but one of the runes
+		ms = d.FuzzySearch([]byte("太阳系金星"), 2)
+		tt.Equal(t, []FuzzyMatch{{[]byte("太阳系金星"), 8, 0}}, ms)
+		ms = d.FuzzySearchRune([]byte("太阳系金星"), 1)
+		tt.Equal(t, 5, len(ms))
+		tt.Equal(t, FuzzyMatch{[]byte("太阳系木星"), 11, 1}, ms[1])
+		tt.Equal(t, FuzzyMatch{[]byte("太阳系金星"), 8, 0}, ms[4])
+
+		tt.Equal(t, 0, len(d.FuzzySearch([]byte("abc"), -1)))
+		tt.Equal(t, 1, len(d.FuzzySearch([]byte("abc"), 0)))
+		tt.Equal(t, len(words), len(d.FuzzySearchRune(nil, 100)))
+	}
+}
+
+func TestFuzzySearchRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "c", "太", "阳", "\xe5"}
+	randKey := func() []byte {
+		var key []byte
+		for n := r.Intn(6) + 1; n > 0; n-- {
+			key = append(key, alphabet[r.Intn(len(alphabet))]...)
+		}
+		return key
+	}
+
+	d := New()
+	for i := 0; i < 500; i++ {
+		err := d.Insert(randKey(), i)
+		tt.Nil(t, err)
+	}
+
+	for i := 0; i < 50; i++ {
+		q, maxDist := randKey(), r.Intn(4)
+
+		var bytesWant, runesWant []FuzzyMatch
+		d.Walk(func(key []byte, val int) bool {
+			key = append([]byte(nil), key...)
+			if dist := levenshtein(q, key); dist <= maxDist {
+				bytesWant = append(bytesWant, FuzzyMatch{key, val, dist})
+			}
+			if dist := levenshtein([]rune(string(q)), []rune(string(key))); dist <= maxDist {
+				runesWant = append(runesWant, FuzzyMatch{key, val, dist})
+			}
+			return true
+		})
+
+		tt.Equal(t, bytesWant, d.FuzzySearch(q, maxDist))
+		tt.Equal(t, runesWant, d.FuzzySearchRune(q, maxDist))
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+const (
+	globLit  = iota // the literal byte
+	globAny         // `?`, any single rune
+	globStar        // `*`, any sequence of the runes
+)
+
+type globToken struct {
+	kind int
+	lit  byte
+}
+
+// glob is the state of the pattern search, the state of the pattern is
+// the token index `i` and the number of the bytes `n` at the end of the key
+// consumed by the `?` or `*` token as an incomplete rune, it is encoded as
+// i<<2 | n. The runes are decoded as utf8.DecodeRune, so an invalid byte is
+// a rune by itself.
+type glob struct {
+	cd   *Cedar
+	toks []globToken
+	key  []byte
+	ids  []int
+}
+
+// compileGlob split the pattern into the tokens, `\` escapes the next byte
+func compileGlob(pattern string) (toks []globToken) {
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == '?':
+			toks = append(toks, globToken{kind: globAny})
+		case c == '*':
+			// the consecutive stars are the same as one
+			if len(toks) == 0 || toks[len(toks)-1].kind != globStar {
+				toks = append(toks, globToken{kind: globStar})
+			}
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			toks = append(toks, globToken{kind: globLit, lit: pattern[i]})
+		default:
+			toks = append(toks, globToken{kind: globLit, lit: c})
+		}
+	}
+	return
+}
+
+// MatchGlob return the node ids of the keys matching the wildcard `pattern`
+// in the same order as Walk, `?` matches any single UTF-8 rune and `*`
+// matches any sequence of the runes, `\` escapes the wildcards. The keys
+// are decoded as utf8.DecodeRune, an invalid byte is a single rune.
+//
+// The trie is walked by the children of the nodes in the lockstep with
+// the pattern, only the subtrees that could match are visited.
+func (cd *Cedar) MatchGlob(pattern string) []int {
+	g := &glob{cd: cd, toks: compileGlob(pattern)}
+	g.visit(0, g.closure(nil, 0))
+	return g.ids
+}
+
+// closure add the state and the states reachable without a byte,
+// which skips the `*` matching the empty sequence.
+func (g *glob) closure(states []int, i int) []int {
+	for {
+		if !slices.Contains(states, i<<2) {
+			states = append(states, i<<2)
+		}
+		if i == len(g.toks) || g.toks[i].kind != globStar {
+			return states
+		}
+		i++
+	}
+}
+
+// feed move the token `i` by the bytes of the key from `start`, the states
+// are added to `next` at the end of the key. The incomplete rune is pending
+// in the state until the next byte, it is decoded byte by byte if `end`.
+func (g *glob) feed(next []int, i, start int, end bool) []int {
+	if start == len(g.key) {
+		if !slices.Contains(next, i<<2) {
+			next = append(next, i<<2)
+		}
+		return next
+	}
+	if i == len(g.toks) {
+		return next
+	}
+
+	tok := g.toks[i]
+	if tok.kind == globLit {
+		if g.key[start] == tok.lit {
+			next = g.feedAll(next, i+1, start+1, end)
+		}
+		return next
+	}
+
+	buf := g.key[start:]
+	if !end && !utf8.FullRune(buf) {
+		if !slices.Contains(next, i<<2|len(buf)) {
+			next = append(next, i<<2|len(buf))
+		}
+		return next
+	}
+
+	_, size := utf8.DecodeRune(buf)
+	if tok.kind == globAny {
+		return g.feedAll(next, i+1, start+size, end)
+	}
+	return g.feedAll(next, i, start+size, end)
+}
+
+// feedAll feed the closure of the token `i`
+func (g *glob) feedAll(next []int, i, start int, end bool) []int {
+	for _, s := range g.closure(nil, i) {
+		next = g.feed(next, s>>2, start, end)
+	}
+	return next
+}
+
+// step move the states by the last byte of the key
+func (g *glob) step(states []int) (next []int) {
+	for _, s := range states {
+		next = g.feed(next, s>>2, len(g.key)-1-s&3, false)
+	}
+	return
+}
+
+// accept reports whether the states match the whole key
+func (g *glob) accept(states []int) bool {
+	for _, s := range states {
+		if slices.Contains(g.feed(nil, s>>2, len(g.key)-s&3, true), len(g.toks)<<2) {
+			return true
+		}
+	}
+	return false
+}
+
+// literal return the bytes of the states if all of them are the literal
+func (g *glob) literal(states []int) (lits []byte, ok bool) {
+	for _, s := range states {
+		i, n := s>>2, s&3
+		if i == len(g.toks) {
+			continue
+		}
+		if n > 0 || g.toks[i].kind != globLit {
+			return nil, false
+		}
+		lits = append(lits, g.toks[i].lit)
+	}
+
+	slices.Sort(lits)
+	return slices.Compact(lits), true
+}
+
+func (g *glob) visit(from int, states []int) {
+	if from != 0 && g.accept(states) {
+		if _, err := g.cd.Value(from); err == nil {
+			g.ids = append(g.ids, from)
+		}
+	}
+
+	// follow the labels directly without the wildcard
+	if lits, ok := g.literal(states); ok {
+		for _, c := range lits {
+			if to, ok := g.cd.childOf(from, c); ok {
+				g.key = append(g.key, c)
+				g.visit(to, g.step(states))
+				g.key = g.key[:len(g.key)-1]
+			}
+		}
+		return
+	}
+
+	g.cd.eachChild(from, func(to int, label byte) bool {
+		g.key = append(g.key, label)
+		if next := g.step(states); len(next) > 0 {
+			g.visit(to, next)
+		}
+		g.key = g.key[:len(g.key)-1]
+		return true
+	})
+}
+
+// MatchGlob return the node ids of the keys matching the wildcard `pattern`
+func (sc *SyncCedar) MatchGlob(pattern string) []int {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.MatchGlob(pattern)
+}
+
+// MatchGlob return the node ids of the keys matching the wildcard `pattern`
+func (s *Snapshot) MatchGlob(pattern string) []int {
+	return s.cd.MatchGlob(pattern)
+}
@@ -0,0 +1,98 @@
+package cedar
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestMatchGlob(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range words {
+			err := d.InsertString(word, i)
+			tt.Nil(t, err)
+		}
+		err := d.InsertString("a*b?", 100)
+		tt.Nil(t, err)
+
+		keys := func(ids []int) (ks []string) {
+			for _, id := range ids {
+				key, err := d.Key(id)
+				tt.Nil(t, err)
+				ks = append(ks, string(key))
+			}
+			return
+		}
+
+		tt.Equal(t, []string{"太阳系土星", "太阳系木星", "太阳系水星", "太阳系火星", "太阳系金星"},
+			keys(d.MatchGlob("太阳系?星")))
+		tt.Equal(t, []string{"this is a cedar."}, keys(d.MatchGlob("this*cedar?")))
+		tt.Equal(t, []string{"this", "this is", "this is a cedar."}, keys(d.MatchGlob("this*")))
+		tt.Equal(t, []string{"abcd", "abcdef"}, keys(d.MatchGlob("a?c*d*")))
+		tt.Equal(t, []string{"太阳系天王星", "太阳系海王星"}, keys(d.MatchGlob("*王星")))
+		tt.Equal(t, []string{"abcdef"}, keys(d.MatchGlob("abcdef")))
+		tt.Equal(t, []string{"a*b?"}, keys(d.MatchGlob(`a\*b\?`)))
+		tt.Equal(t, len(words)+1, len(d.MatchGlob("**")))
+
+		tt.Equal(t, 0, len(d.MatchGlob("")))
+		tt.Equal(t, 0, len(d.MatchGlob("abcde")))
+		tt.Equal(t, 2, len(d.MatchGlob("太阳系??星")))
+		tt.Equal(t, 0, len(d.MatchGlob("太阳系???星")))
+
+		// the invalid byte is a rune, and so is the truncated rune
+		for _, key := range []string{"\xffa", "\xe5a", "x\xf8", "x\xe5\xa4"} {
+			err := d.InsertString(key, 0)
+			tt.Nil(t, err)
+		}
+		tt.Equal(t, []string{"aa", "\xe5a", "\xffa"}, keys(d.MatchGlob("?a")))
+		tt.Equal(t, []string{"x\xf8"}, keys(d.MatchGlob("x?")))
+		tt.Equal(t, []string{"x\xe5\xa4", "x\xf8"}, keys(d.MatchGlob("x*")))
+		tt.Equal(t, []string{"x\xe5\xa4"}, keys(d.MatchGlob("x??")))
+	}
+}
+
+func TestMatchGlobRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	// the keys have the invalid and incomplete runes, the regexp decodes
+	// them as utf8.RuneError of a byte like MatchGlob
+	alphabet := []string{"a", "b", "太", "阳", "\xe5", "\xa4", "\xff", "\xf8", "\xe5\xa4"}
+	wildcards := []string{"?", "*", "a", "b", "太", "阳"}
+	randString := func(alphabet []string, n int) (s string) {
+		for n = r.Intn(n) + 1; n > 0; n-- {
+			s += alphabet[r.Intn(len(alphabet))]
+		}
+		return
+	}
+
+	d := New()
+	for i := 0; i < 300; i++ {
+		err := d.InsertString(randString(alphabet, 6), i)
+		tt.Nil(t, err)
+	}
+
+	for i := 0; i < 200; i++ {
+		pattern := randString(wildcards, 6)
+		re := regexp.MustCompile("^(?s:" + strings.NewReplacer(
+			"?", ".", "*", ".*").Replace(pattern) + ")$")
+
+		var want []string
+		d.Walk(func(key []byte, val int) bool {
+			if re.Match(key) {
+				want = append(want, string(key))
+			}
+			return true
+		})
+
+		var got []string
+		for _, id := range d.MatchGlob(pattern) {
+			key, err := d.Key(id)
+			tt.Nil(t, err)
+			got = append(got, string(key))
+		}
+		tt.Equal(t, want, got, pattern)
+	}
+}
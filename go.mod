@@ -1,5 +1,5 @@
 module github.com/vcaesar/cedar
 
-go 1.17
+go 1.23
 
 require github.com/vcaesar/tt v0.20.1
@@ -0,0 +1,38 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import "iter"
+
+// Walk call fn with every key and its value in the trie, in the
+// lexicographic order when the trie is ordered; stop when fn return false.
+// The key is reused by the walk, copy it if it is needed after fn return.
+func (cd *Cedar) Walk(fn func(key []byte, val int) bool) {
+	cd.walk(0, nil, func(key []byte, id, val int) bool {
+		return fn(key, val)
+	})
+}
+
+// All return an iterator over the key/value pairs in the same order as Walk,
+// the key is reused by the iterator as well.
+func (cd *Cedar) All() iter.Seq2[[]byte, int] {
+	return func(yield func([]byte, int) bool) {
+		cd.Walk(yield)
+	}
+}
+
+// walk the subtree of `from` in the depth-first order, the node's own value
+// is visited before its children, which is the terminal node of label 0.
+func (cd *Cedar) walk(from int, key []byte, fn func(key []byte, id, val int) bool) bool {
+	if from != 0 {
+		if val, err := cd.Value(from); err == nil && !fn(key, from, val) {
+			return false
+		}
+	}
+
+	return cd.eachChild(from, func(to int, label byte) bool {
+		return cd.walk(to, append(key, label), fn)
+	})
+}
@@ -0,0 +1,39 @@
+package cedar
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestWalk(t *testing.T) {
+	sorted := append([]string(nil), words...)
+	sort.Strings(sorted)
+
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range words {
+			err := d.Insert([]byte(word), i)
+			tt.Nil(t, err)
+		}
+
+		var keys []string
+		d.Walk(func(key []byte, val int) bool {
+			keys = append(keys, string(key))
+			tt.Equal(t, words[val], string(key))
+			return true
+		})
+		tt.Equal(t, sorted, keys)
+
+		keys = keys[:0]
+		for key, val := range d.All() {
+			tt.Equal(t, words[val], string(key))
+			keys = append(keys, string(key))
+			if len(keys) == 3 {
+				break
+			}
+		}
+		tt.Equal(t, sorted[:3], keys)
+	}
+}
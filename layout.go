@@ -0,0 +1,18 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !cedar32
+
+package cedar
+
+// The default layout uses int for the fields of Node and Block,
+// build with the `cedar32` tag for the compact layout.
+type (
+	nodeInt  = int // the type of `baseV` and `check`
+	blockInt = int // the type of the block indexes and `eHead`
+	slotInt  = int // the type of the slot counters, range 0-257
+)
+
+// ValLimit cedar value limit
+const ValLimit = int(^uint(0) >> 1)
@@ -0,0 +1,21 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build cedar32
+
+package cedar
+
+import "math"
+
+// The compact layout of the `cedar32` tag uses int32 and int16 as the C++ cedar,
+// a Node is 8 bytes instead of 16 on the 64-bit platforms, and the values
+// are limited to int32.
+type (
+	nodeInt  = int32 // the type of `baseV` and `check`
+	blockInt = int32 // the type of the block indexes and `eHead`
+	slotInt  = int16 // the type of the slot counters, range 0-257
+)
+
+// ValLimit cedar value limit
+const ValLimit = int(math.MaxInt32)
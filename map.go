@@ -0,0 +1,100 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+// Map is the trie with values of any type, the values are kept in a slab
+// and the trie stores their indexes.
+type Map[V any] struct {
+	cd   *Cedar
+	vals []V
+	free []int // the freed indexes of vals, reused by Insert
+}
+
+// NewMap initialize the Map for further use
+func NewMap[V any](reduced ...bool) *Map[V] {
+	return &Map[V]{cd: New(reduced...)}
+}
+
+// Insert the key for the value, the old value is replaced if the key exists
+func (m *Map[V]) Insert(key []byte, val V) error {
+	if i, err := m.cd.Get(key); err == nil {
+		m.vals[i] = val
+		return nil
+	}
+
+	i := len(m.vals)
+	if n := len(m.free); n > 0 {
+		i = m.free[n-1]
+		m.free = m.free[:n-1]
+	} else {
+		var zero V
+		m.vals = append(m.vals, zero)
+	}
+
+	if err := m.cd.Insert(key, i); err != nil {
+		m.free = append(m.free, i)
+		return err
+	}
+
+	m.vals[i] = val
+	return nil
+}
+
+// Get get the value of the key
+func (m *Map[V]) Get(key []byte) (val V, ok bool) {
+	i, err := m.cd.Get(key)
+	if err != nil {
+		return
+	}
+
+	return m.vals[i], true
+}
+
+// Delete the key from the map, its slot is reused by the later Insert
+func (m *Map[V]) Delete(key []byte) error {
+	i, err := m.cd.Get(key)
+	if err != nil {
+		return ErrNoKey
+	}
+
+	if err := m.cd.Delete(key); err != nil {
+		return err
+	}
+
+	var zero V
+	m.vals[i] = zero
+	m.free = append(m.free, i)
+	return nil
+}
+
+// PrefixMatch return the values of the common prefix
+// in the dictionary with the `key`
+func (m *Map[V]) PrefixMatch(key []byte, n ...int) []V {
+	return m.values(m.cd.PrefixMatch(key, n...))
+}
+
+// PrefixPredict return the values of the words in the dictionary
+// that has `key` as their prefix
+func (m *Map[V]) PrefixPredict(key []byte, n ...int) []V {
+	return m.values(m.cd.PrefixPredict(key, n...))
+}
+
+// Walk call fn with every key and its value in the map, like Cedar.Walk
+func (m *Map[V]) Walk(fn func(key []byte, val V) bool) {
+	m.cd.Walk(func(key []byte, i int) bool {
+		return fn(key, m.vals[i])
+	})
+}
+
+func (m *Map[V]) values(ids []int) []V {
+	vals := make([]V, 0, len(ids))
+	for _, id := range ids {
+		if i, err := m.cd.Value(id); err == nil {
+			vals = append(vals, m.vals[i])
+		}
+	}
+
+	return vals
+}
@@ -0,0 +1,59 @@
+package cedar
+
+import (
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+type planet struct {
+	name  string
+	order int
+}
+
+func TestMap(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		m := NewMap[planet](reduced)
+		err := m.Insert([]byte("太阳系水星"), planet{"mercury", 1})
+		tt.Nil(t, err)
+		err = m.Insert([]byte("太阳系金星"), planet{"venus", 2})
+		tt.Nil(t, err)
+		err = m.Insert([]byte("太阳系地球"), planet{"earth", 0})
+		tt.Nil(t, err)
+		err = m.Insert([]byte("太阳系地球"), planet{"earth", 3})
+		tt.Nil(t, err)
+		err = m.Insert([]byte("太阳系"), planet{"sun", 0})
+		tt.Nil(t, err)
+
+		p, ok := m.Get([]byte("太阳系地球"))
+		tt.True(t, ok)
+		tt.Equal(t, planet{"earth", 3}, p)
+		_, ok = m.Get([]byte("太阳"))
+		tt.False(t, ok)
+
+		ps := m.PrefixMatch([]byte("太阳系地球"))
+		tt.Equal(t, []planet{{"sun", 0}, {"earth", 3}}, ps)
+		ps = m.PrefixPredict([]byte("太阳系"))
+		tt.Equal(t, []planet{{"sun", 0}, {"earth", 3}, {"mercury", 1}, {"venus", 2}}, ps)
+
+		// the freed slot is reused
+		err = m.Delete([]byte("太阳系水星"))
+		tt.Nil(t, err)
+		err = m.Delete([]byte("太阳系水星"))
+		tt.Equal(t, ErrNoKey, err)
+		_, ok = m.Get([]byte("太阳系水星"))
+		tt.False(t, ok)
+
+		err = m.Insert([]byte("太阳系火星"), planet{"mars", 4})
+		tt.Nil(t, err)
+		tt.Nil(t, m.cd.Validate())
+		tt.Equal(t, 4, len(m.vals))
+
+		var names []string
+		m.Walk(func(key []byte, p planet) bool {
+			names = append(names, p.name)
+			return true
+		})
+		tt.Equal(t, []string{"sun", "earth", "mars", "venus"}, names)
+	}
+}
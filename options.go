@@ -0,0 +1,60 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+// Option set the option of the Cedar created by NewWithOptions
+type Option func(*Cedar)
+
+// WithReduced option the reduced trie, the default is true
+func WithReduced(reduced bool) Option {
+	return func(cd *Cedar) {
+		cd.Reduced = reduced
+	}
+}
+
+// WithOrdered option keep the siblings sorted by the label, the default is true;
+// the unordered trie inserts faster, but Walk and PrefixPredict
+// visit the keys in the insertion order of the labels.
+func WithOrdered(ordered bool) Option {
+	return func(cd *Cedar) {
+		cd.ordered = ordered
+	}
+}
+
+// WithMaxTrial option the number of times a block is probed for the free slots
+// before it is closed, the default is 1; it is at least 1
+// and at most 256 which is the size of the block.
+func WithMaxTrial(n int) Option {
+	return func(cd *Cedar) {
+		cd.maxTrial = slotInt(min(max(n, 1), 256))
+	}
+}
+
+// WithInitialCapacity option pre-size the trie to hold n nodes,
+// so the arrays are not grown repeatedly by the insertion.
+func WithInitialCapacity(n int) Option {
+	return func(cd *Cedar) {
+		cd.capacity = max(n, 256)
+	}
+}
+
+// NewWithOptions initialize the Cedar with the options
+func NewWithOptions(opts ...Option) *Cedar {
+	cd := &Cedar{
+		Reduced:  true,
+		capacity: 256,
+		ordered:  true,
+		maxTrial: 1,
+	}
+	for _, opt := range opts {
+		opt(cd)
+	}
+
+	capacity := cd.capacity
+	cd.init()
+	cd.reserve(capacity)
+
+	return cd
+}
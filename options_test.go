@@ -0,0 +1,76 @@
+package cedar
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	d := NewWithOptions()
+	tt.True(t, d.Reduced)
+	tt.True(t, d.ordered)
+	tt.Equal(t, 1, int(d.maxTrial))
+	tt.Equal(t, 256, d.Stats().Capacity)
+
+	d = NewWithOptions(WithReduced(false), WithOrdered(false),
+		WithMaxTrial(4), WithInitialCapacity(5000))
+	tt.False(t, d.Reduced)
+	tt.False(t, d.ordered)
+	tt.Equal(t, 4, int(d.maxTrial))
+	tt.Equal(t, 5120, d.Stats().Capacity)
+	tt.Equal(t, 256, d.Stats().Size)
+	tt.Nil(t, d.Validate())
+
+	tt.Equal(t, 1, int(NewWithOptions(WithMaxTrial(0)).maxTrial))
+	tt.Equal(t, 256, int(NewWithOptions(WithMaxTrial(1000)).maxTrial))
+}
+
+func TestOptions(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		for _, ordered := range []bool{true, false} {
+			d := NewWithOptions(WithReduced(reduced), WithOrdered(ordered),
+				WithMaxTrial(3), WithInitialCapacity(1<<16))
+			for i := 0; i < 10000; i++ {
+				err := d.Insert([]byte("key"+strconv.Itoa(i)), i)
+				tt.Nil(t, err)
+			}
+			for i := 0; i < 10000; i += 3 {
+				err := d.Delete([]byte("key" + strconv.Itoa(i)))
+				tt.Nil(t, err)
+			}
+			tt.Nil(t, d.Validate())
+			tt.Equal(t, 6666, d.Len())
+
+			for i := 0; i < 10000; i++ {
+				val, err := d.Get([]byte("key" + strconv.Itoa(i)))
+				if i%3 == 0 {
+					tt.NotNil(t, err)
+					continue
+				}
+				tt.Nil(t, err)
+				tt.Equal(t, i, val)
+			}
+			tt.Equal(t, 742, len(d.PrefixPredict([]byte("key1"))))
+
+			// the options are kept by the copies of the trie
+			var buf bytes.Buffer
+			_, err := d.WriteTo(&buf)
+			tt.Nil(t, err)
+			for _, c := range []*Cedar{d.Snapshot().cd, New()} {
+				if c.Len() == 0 {
+					_, err = c.ReadFrom(&buf)
+					tt.Nil(t, err)
+				}
+				c.Compact()
+				tt.Nil(t, c.Validate())
+				tt.Equal(t, reduced, c.Reduced)
+				tt.Equal(t, ordered, c.ordered)
+				tt.Equal(t, 3, int(c.maxTrial))
+				tt.Equal(t, 6666, c.Len())
+			}
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"regexp/syntax"
+	"slices"
+	"unicode/utf8"
+)
+
+// regexpSearch is the state of the regexp search, the threads of the
+// NFA of the program are run in the lockstep with the path of the trie.
+type regexpSearch struct {
+	cd       *Cedar
+	prog     *syntax.Prog
+	anchored bool // the program begins with `^`, no thread is started after the first rune
+
+	key []byte
+	ids []int
+}
+
+// MatchProg return the node ids of the keys matched by the `prog`
+// in the same order as Walk, a key is matched as regexp.Match does,
+// so the program is unanchored unless it has `^` or `$`.
+//
+// The threads of the program are run along the paths of the trie,
+// the subtree is skipped when no thread is alive, and the whole
+// subtree is matched once a match is found before its end.
+func (cd *Cedar) MatchProg(prog *syntax.Prog) []int {
+	rs := &regexpSearch{
+		cd:       cd,
+		prog:     prog,
+		anchored: prog.StartCond()&syntax.EmptyBeginText != 0,
+	}
+	rs.visit(0, []uint32{uint32(prog.Start)}, -1, 0)
+	return rs.ids
+}
+
+// MatchRegexp compile the regular expression with the Perl syntax
+// as regexp.Compile does and return the node ids of the keys matched by it
+func (cd *Cedar) MatchRegexp(expr string) ([]int, error) {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return nil, err
+	}
+
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return nil, err
+	}
+	return cd.MatchProg(prog), nil
+}
+
+// closure follow the empty transitions of the threads `pcs` at the position
+// between the runes `r1` and `r2`, -1 is the begin or the end of the key;
+// it return the threads waiting for a rune and whether the program matched.
+func (rs *regexpSearch) closure(pcs []uint32, r1, r2 rune) (threads []uint32, matched bool) {
+	ctx := syntax.EmptyOpContext(r1, r2)
+	seen := make([]bool, len(rs.prog.Inst))
+
+	stack := slices.Clone(pcs)
+	slices.Reverse(stack)
+	for len(stack) > 0 {
+		pc := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[pc] {
+			continue
+		}
+		seen[pc] = true
+
+		switch inst := &rs.prog.Inst[pc]; inst.Op {
+		case syntax.InstMatch:
+			return nil, true
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, inst.Arg, inst.Out)
+		case syntax.InstCapture, syntax.InstNop:
+			stack = append(stack, inst.Out)
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg)&^ctx == 0 {
+				stack = append(stack, inst.Out)
+			}
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			threads = append(threads, pc)
+		}
+	}
+
+	return
+}
+
+// step move the threads by the rune `r`, a new thread is started
+// at the next rune if the program is unanchored.
+func (rs *regexpSearch) step(threads []uint32, r rune) (pcs []uint32) {
+	for _, pc := range threads {
+		inst := &rs.prog.Inst[pc]
+
+		ok := false
+		switch inst.Op {
+		case syntax.InstRune, syntax.InstRune1:
+			ok = inst.MatchRune(r)
+		case syntax.InstRuneAny:
+			ok = true
+		case syntax.InstRuneAnyNotNL:
+			ok = r != '\n'
+		}
+
+		if ok && !slices.Contains(pcs, inst.Out) {
+			pcs = append(pcs, inst.Out)
+		}
+	}
+
+	if start := uint32(rs.prog.Start); !rs.anchored && !slices.Contains(pcs, start) {
+		pcs = append(pcs, start)
+	}
+	return
+}
+
+// advance decode the runes of the bytes `b` and move the threads by them,
+// it report whether the program matched before a rune, and the bytes of
+// the incomplete rune are left if `flush` is false.
+func (rs *regexpSearch) advance(pcs []uint32, prev rune, b []byte, flush bool) (
+	[]uint32, rune, int, bool) {
+	for len(b) > 0 && (flush || utf8.FullRune(b)) {
+		r, size := utf8.DecodeRune(b)
+		threads, matched := rs.closure(pcs, prev, r)
+		if matched {
+			return nil, r, 0, true
+		}
+
+		pcs, prev, b = rs.step(threads, r), r, b[size:]
+	}
+
+	return pcs, prev, len(b), false
+}
+
+// visit the node `from` with the threads `pcs` before the next rune,
+// `prev` is the last rune and `pending` is the number of bytes of the
+// incomplete rune at the end of the key.
+func (rs *regexpSearch) visit(from int, pcs []uint32, prev rune, pending int) {
+	if _, err := rs.cd.Value(from); from != 0 && err == nil {
+		p, r, _, matched := rs.advance(pcs, prev, rs.key[len(rs.key)-pending:], true)
+		if !matched {
+			_, matched = rs.closure(p, r, -1)
+		}
+		if matched {
+			rs.ids = append(rs.ids, from)
+		}
+	}
+
+	rs.cd.eachChild(from, func(to int, label byte) bool {
+		rs.key = append(rs.key, label)
+
+		p, r, n, matched := rs.advance(pcs, prev, rs.key[len(rs.key)-pending-1:], false)
+		switch {
+		case matched:
+			// every key of the subtree has the matched prefix
+			rs.cd.walk(to, rs.key, func(key []byte, id, val int) bool {
+				rs.ids = append(rs.ids, id)
+				return true
+			})
+		case len(p) > 0:
+			rs.visit(to, p, r, n)
+		}
+
+		rs.key = rs.key[:len(rs.key)-1]
+		return true
+	})
+}
+
+// MatchProg return the node ids of the keys matched by the `prog`
+func (sc *SyncCedar) MatchProg(prog *syntax.Prog) []int {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.MatchProg(prog)
+}
+
+// MatchRegexp return the node ids of the keys matched by the regular expression
+func (sc *SyncCedar) MatchRegexp(expr string) ([]int, error) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.MatchRegexp(expr)
+}
+
+// MatchProg return the node ids of the keys matched by the `prog`
+func (s *Snapshot) MatchProg(prog *syntax.Prog) []int {
+	return s.cd.MatchProg(prog)
+}
+
+// MatchRegexp return the node ids of the keys matched by the regular expression
+func (s *Snapshot) MatchRegexp(expr string) ([]int, error) {
+	return s.cd.MatchRegexp(expr)
+}
@@ -0,0 +1,82 @@
+package cedar
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestMatchRegexp(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range words {
+			err := d.InsertString(word, i)
+			tt.Nil(t, err)
+		}
+
+		keys := func(ids []int, err error) (ks []string) {
+			tt.Nil(t, err)
+			for _, id := range ids {
+				key, err := d.Key(id)
+				tt.Nil(t, err)
+				ks = append(ks, string(key))
+			}
+			return
+		}
+
+		tt.Equal(t, []string{"abc", "abcd"}, keys(d.MatchRegexp(`^ab[c-e]+$`)))
+		tt.Equal(t, []string{"abc", "abcd", "abcdef"}, keys(d.MatchRegexp(`^ab[c-e]+`)))
+		tt.Equal(t, []string{"太阳系天王星", "太阳系海王星"}, keys(d.MatchRegexp(`系.王`)))
+		tt.Equal(t, []string{"this", "this is"}, keys(d.MatchRegexp(`(?i)^THIS\b\s*\w*$`)))
+		tt.Equal(t, []string{"cedar", "this is a cedar."}, keys(d.MatchRegexp(`cedar`)))
+		tt.Equal(t, len(words), len(keys(d.MatchRegexp(``))))
+		tt.Equal(t, 0, len(keys(d.MatchRegexp(`^$`))))
+
+		_, err := d.MatchRegexp(`a(b`)
+		tt.NotNil(t, err)
+	}
+}
+
+func TestMatchRegexpRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", " ", "\n", "太", "阳", "\xe5", "\xa4"}
+	exprs := []string{
+		`^ab`, `b$`, `^a*b+$`, `a.b`, `(?s)a.b`, `\ba`, `\Bb\b`, `太.`, `阳$`,
+		`[^a]b`, `^(ab|太阳)+`, `(?m)^b`, `(?m)a$`, `\x{FFFD}`, `^.{3}$`, `(?i)AB`,
+		`^\s`, `a|b`, `^$`, `(a*)*b`,
+	}
+
+	d := New()
+	for i := 0; i < 500; i++ {
+		var key string
+		for n := r.Intn(6) + 1; n > 0; n-- {
+			key += alphabet[r.Intn(len(alphabet))]
+		}
+		err := d.InsertString(key, i)
+		tt.Nil(t, err)
+	}
+
+	for _, expr := range exprs {
+		re := regexp.MustCompile(expr)
+
+		var want []string
+		d.Walk(func(key []byte, val int) bool {
+			if re.Match(key) {
+				want = append(want, string(key))
+			}
+			return true
+		})
+
+		ids, err := d.MatchRegexp(expr)
+		tt.Nil(t, err)
+		var got []string
+		for _, id := range ids {
+			key, err := d.Key(id)
+			tt.Nil(t, err)
+			got = append(got, string(key))
+		}
+		tt.Equal(t, want, got, expr)
+	}
+}
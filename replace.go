@@ -0,0 +1,86 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"slices"
+	"sort"
+)
+
+// Replacer replace the dictionary hits in the text by the leftmost-longest
+// Aho-Corasick matching, the hit is replaced by the replacement of its key.
+//
+// The Replacer is not safe for concurrent use, because the automaton is
+// rebuilt when the dictionary is modified.
+type Replacer struct {
+	ac *AhoCorasick
+	fn func(key []byte, val int) []byte
+}
+
+// NewReplacer return the Replacer that replace the keys of `repls` by
+// their values; the dictionary `cd` is shared if it is not nil, and its keys
+// not in `repls` are kept, or a new dictionary of the keys of `repls` is used.
+func NewReplacer(cd *Cedar, repls map[string]string) *Replacer {
+	if cd == nil {
+		keys := make([]string, 0, len(repls))
+		for key := range repls {
+			if key != "" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		cd = New()
+		for i, key := range keys {
+			cd.InsertString(key, i)
+		}
+	}
+
+	return NewReplacerFunc(cd, func(key []byte, val int) []byte {
+		if repl, ok := repls[string(key)]; ok {
+			return bytesOf(repl)
+		}
+		return key
+	})
+}
+
+// NewReplacerFunc return the Replacer that replace the keys of the
+// dictionary `cd` by the result of fn with the key and its value,
+// the key is a slice of the text and must not be modified.
+func NewReplacerFunc(cd *Cedar, fn func(key []byte, val int) []byte) *Replacer {
+	return &Replacer{ac: NewAhoCorasick(cd), fn: fn}
+}
+
+// Replace return a copy of the text with all the hits replaced
+func (r *Replacer) Replace(text []byte) []byte {
+	if buf, ok := r.replace(text); ok {
+		return buf
+	}
+	return slices.Clone(text)
+}
+
+// ReplaceString return `s` with all the hits replaced
+func (r *Replacer) ReplaceString(s string) string {
+	if buf, ok := r.replace(bytesOf(s)); ok {
+		return string(buf)
+	}
+	return s
+}
+
+// replace the hits of the text, it is false if there is no hit
+func (r *Replacer) replace(text []byte) ([]byte, bool) {
+	ms := r.ac.Match(text, MatchLeftmostLongest)
+	if len(ms) == 0 {
+		return nil, false
+	}
+
+	buf, last := make([]byte, 0, len(text)), 0
+	for _, m := range ms {
+		buf = append(buf, text[last:m.Start]...)
+		buf = append(buf, r.fn(text[m.Start:m.End], m.Value)...)
+		last = m.End
+	}
+	return append(buf, text[last:]...), true
+}
@@ -0,0 +1,52 @@
+package cedar
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestReplacer(t *testing.T) {
+	repls := map[string]string{
+		"a": "1", "abc": "3", "太阳": "sun", "太阳系": "solar system", "": "x",
+	}
+	r := NewReplacer(nil, repls)
+	tt.Equal(t, "1b3 solar system, sun!", r.ReplaceString("ababc 太阳系, 太阳!"))
+	tt.Equal(t, "nothing", r.ReplaceString("nothing"))
+	tt.Equal(t, "", r.ReplaceString(""))
+	tt.Equal(t, []byte("11"), r.Replace([]byte("aa")))
+
+	// the same as strings.Replacer, which is leftmost-first on the arguments
+	text := "abcabdxaab太阳系太阳"
+	sr := strings.NewReplacer("abc", "3", "a", "1", "太阳系", "solar system", "太阳", "sun")
+	tt.Equal(t, sr.Replace(text), r.ReplaceString(text))
+
+	// the shared dictionary, the keys without the replacement are kept
+	d := New()
+	for i, word := range words {
+		err := d.InsertString(word, i)
+		tt.Nil(t, err)
+	}
+	r = NewReplacer(d, repls)
+	tt.Equal(t, "abcd, abcdef, solar system, 太阳系土星", r.ReplaceString("abcd, abcdef, 太阳系, 太阳系土星"))
+
+	r = NewReplacerFunc(d, func(key []byte, val int) []byte {
+		return bytes.Repeat([]byte("*"), val)
+	})
+	text = "this is a cedar.ab"
+	tt.Equal(t, strings.Repeat("*", 17)+"**", r.ReplaceString(text))
+
+	// the automaton is rebuilt after the dictionary is modified
+	err := d.DeleteString("this is a cedar.")
+	tt.Nil(t, err)
+	tt.Equal(t, strings.Repeat("*", 16)+"  "+strings.Repeat("*", 18)+".**",
+		r.ReplaceString(text))
+
+	src := []byte("no hit")
+	dst := r.Replace(src)
+	tt.Equal(t, src, dst)
+	dst[0] = 'N'
+	tt.Equal(t, "no hit", string(src))
+}
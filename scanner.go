@@ -0,0 +1,147 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"io"
+)
+
+// defaultBufSize is the size of the chunk read by the Scanner
+const defaultBufSize = 32 * 1024
+
+// maxEmptyReads is the number of the empty reads before the Scanner gives up
+const maxEmptyReads = 100
+
+// ScanMatch is the hit found by the Scanner, the offsets are int64
+// since the stream could be longer than an int on the 32-bit platforms.
+type ScanMatch struct {
+	Start, End int64 // the byte offsets of the hit in the stream, End is exclusive
+	Value      int   // the value stored with the key
+	ID         int   // the node id of the key, as returned by Jump
+}
+
+// Scanner find the dictionary hits in the stream by the Aho-Corasick
+// automaton, the stream is read in the chunks and the state of the automaton
+// is carried across them, so the hit split by the reads is still found.
+//
+// The offsets of the hits are of the whole stream. The trie should not be
+// modified while scanning, or the automaton restarts from the root.
+type Scanner struct {
+	ac *AhoCorasick
+	t  *acTable // the links the state belongs to
+	r  io.Reader
+
+	buf    []byte
+	state  int
+	offset int64
+
+	kind  MatchKind
+	carry []byte // the tail of the last chunk which is scanned again by the leftmost kinds
+	work  []byte
+
+	ms   []Match // the hits of the last chunk, relative to `base`
+	base int64   // the offset of the scanned bytes of the last chunk
+	i    int     // the next hit in ms
+	m    ScanMatch
+	err  error
+}
+
+// NewScanner return the Scanner to read from `r`, the hits are reported
+// by the `kind` as ScanMatch, the default is MatchOverlapping.
+func (ac *AhoCorasick) NewScanner(r io.Reader, kind ...MatchKind) *Scanner {
+	s := &Scanner{ac: ac, r: r}
+	if len(kind) > 0 {
+		s.kind = kind[0]
+	}
+	return s
+}
+
+// Buffer set the buffer to read the chunks, it must be called before Scan;
+// the default size is 32KB.
+func (s *Scanner) Buffer(buf []byte) {
+	s.buf = buf[:cap(buf)]
+}
+
+// Scan advance the Scanner to the next hit, which is available by Match,
+// it return false when the stream is ended or an error occurs.
+func (s *Scanner) Scan() bool {
+	if len(s.buf) == 0 {
+		s.buf = make([]byte, defaultBufSize)
+	}
+
+	for empty := 0; ; {
+		if s.i < len(s.ms) {
+			m := s.ms[s.i]
+			s.m = ScanMatch{
+				Start: s.base + int64(m.Start), End: s.base + int64(m.End),
+				Value: m.Value, ID: m.ID,
+			}
+			s.i++
+			return true
+		}
+		if s.err != nil {
+			return false
+		}
+
+		if s.t == nil || s.t.stale() {
+			s.t = s.ac.links()
+			s.state = 0
+		}
+
+		n, err := s.r.Read(s.buf)
+		if n > 0 || err != nil && len(s.carry) > 0 {
+			s.feed(s.buf[:n], err != nil)
+			s.i = 0
+			empty = 0
+		}
+
+		switch {
+		case err != nil:
+			s.err = err
+		case n == 0:
+			empty++
+			if empty >= maxEmptyReads {
+				s.err = io.ErrNoProgress
+			}
+		}
+	}
+}
+
+// feed scan the chunk, it is the last one if `final` is true,
+// the hits are relative to the scanned bytes so they fit in an int.
+func (s *Scanner) feed(chunk []byte, final bool) {
+	if s.kind == MatchOverlapping {
+		s.state, s.ms = s.t.scan(s.state, chunk, 0, s.ms[:0])
+		s.base = s.offset
+		s.offset += int64(len(chunk))
+		return
+	}
+
+	// the hit of the carried bytes may be changed by the chunk
+	s.work = append(append(s.work[:0], s.carry...), chunk...)
+	var keep int
+	s.ms, keep = s.t.leftmost(s.work, 0, s.kind, final, s.ms[:0])
+	s.base = s.offset - int64(len(s.carry))
+	s.carry = append(s.carry[:0], s.work[keep:]...)
+	s.offset += int64(len(chunk))
+}
+
+// Match return the hit found by the last Scan
+func (s *Scanner) Match() ScanMatch {
+	return s.m
+}
+
+// Offset return the number of bytes read from the stream
+func (s *Scanner) Offset() int64 {
+	return s.offset
+}
+
+// Err return the first error except io.EOF of the Scanner
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
@@ -0,0 +1,86 @@
+package cedar
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/vcaesar/tt"
+)
+
+type emptyReader struct{}
+
+func (emptyReader) Read([]byte) (int, error) { return 0, nil }
+
+func scanAll(s *Scanner) (ms []Match) {
+	for s.Scan() {
+		m := s.Match()
+		ms = append(ms, Match{Start: int(m.Start), End: int(m.End), Value: m.Value, ID: m.ID})
+	}
+	return
+}
+
+func TestScanner(t *testing.T) {
+	ac := NewAhoCorasick(nil)
+	for i, key := range []string{"he", "she", "his", "hers", "太阳", "太阳系"} {
+		err := ac.Insert([]byte(key), i)
+		tt.Nil(t, err)
+	}
+
+	text := []byte(strings.Repeat("ushers 我们的太阳系 this", 1000))
+	want := ac.Match(text)
+	tt.Equal(t, 6000, len(want))
+
+	for _, r := range []io.Reader{
+		bytes.NewReader(text),
+		iotest.OneByteReader(bytes.NewReader(text)),
+		iotest.HalfReader(bytes.NewReader(text)),
+		iotest.DataErrReader(bytes.NewReader(text)),
+	} {
+		s := ac.NewScanner(r)
+		tt.Equal(t, want, scanAll(s))
+		tt.Nil(t, s.Err())
+		tt.Equal(t, int64(len(text)), s.Offset())
+	}
+
+	// the chunk is smaller than the keys
+	s := ac.NewScanner(bytes.NewReader(text))
+	s.Buffer(make([]byte, 2))
+	tt.Equal(t, want, scanAll(s))
+
+	// the leftmost hits are the same across the chunks
+	for _, kind := range []MatchKind{MatchLeftmostFirst, MatchLeftmostLongest} {
+		want := ac.Match(text, kind)
+		tt.Equal(t, 3000, len(want))
+		for _, size := range []int{1, 2, 7, 4096} {
+			s := ac.NewScanner(iotest.HalfReader(bytes.NewReader(text)), kind)
+			s.Buffer(make([]byte, size))
+			tt.Equal(t, want, scanAll(s))
+			tt.Nil(t, s.Err())
+		}
+	}
+
+	// the offsets of the stream are int64 on the 32-bit platforms too
+	s = ac.NewScanner(bytes.NewReader([]byte("ushers")), MatchLeftmostLongest)
+	s.offset = 1 << 33
+	id, err := ac.Jump([]byte("she"), 0)
+	tt.Nil(t, err)
+	tt.True(t, s.Scan())
+	tt.Equal(t, ScanMatch{Start: 1<<33 + 1, End: 1<<33 + 4, Value: 1, ID: id}, s.Match())
+	tt.False(t, s.Scan())
+	tt.Equal(t, int64(1<<33+6), s.Offset())
+
+	errRead := errors.New("read error")
+	r := io.MultiReader(bytes.NewReader([]byte("ushers")), iotest.ErrReader(errRead))
+	s = ac.NewScanner(r)
+	tt.Equal(t, 3, len(scanAll(s)))
+	tt.Equal(t, errRead, s.Err())
+	tt.False(t, s.Scan())
+
+	s = ac.NewScanner(emptyReader{})
+	tt.False(t, s.Scan())
+	tt.Equal(t, io.ErrNoProgress, s.Err())
+}
@@ -0,0 +1,196 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"slices"
+	"unicode/utf8"
+)
+
+// Token is a span of the text cut by the segmentation
+type Token struct {
+	Start, End int // the byte offsets of the token in the text, End is exclusive
+	Value      int // the value of the word, NoVal if it is an unknown rune
+}
+
+// SegmentMode is the direction of the maximum matching
+type SegmentMode int
+
+const (
+	// Forward match the longest word from the begin of the text
+	Forward SegmentMode = iota
+	// Backward match the longest word from the end of the text
+	Backward
+	// Bidirectional take the better of the forward and backward results,
+	// the one with fewer tokens, then fewer single runes, then the backward one.
+	Bidirectional
+)
+
+// Segmenter cut the text into the words of the dictionary by the maximum
+// matching, the unknown runes are the tokens by themselves.
+//
+// The backward matching uses the trie of the reversed keys, which is built
+// on the first use and rebuilt after the dictionary is modified;
+// the Segmenter is not safe for concurrent use.
+type Segmenter struct {
+	cd   *Cedar
+	mode SegmentMode
+
+	rev *Cedar // the reversed keys of cd
+	gen uint64
+
+	weight   Weight
+	total    float64 // the log of the sum of the frequencies, used by Viterbi
+	totalGen uint64  // the gen+1 of the total
+}
+
+// NewSegmenter create the Segmenter on the dictionary `cd`, the default mode is Forward
+func NewSegmenter(cd *Cedar, mode ...SegmentMode) *Segmenter {
+	s := &Segmenter{cd: cd}
+	if len(mode) > 0 {
+		s.mode = mode[0]
+	}
+	return s
+}
+
+// Tokenize cut the text by the forward maximum matching
+func (cd *Cedar) Tokenize(text []byte) []Token {
+	return cd.forward(text)
+}
+
+// Segment cut the text by the mode of the Segmenter
+func (s *Segmenter) Segment(text []byte) []Token {
+	switch s.mode {
+	case Backward:
+		return s.Backward(text)
+	case Bidirectional:
+		return s.Bidirectional(text)
+	}
+	return s.Forward(text)
+}
+
+// Forward cut the text by the forward maximum matching
+func (s *Segmenter) Forward(text []byte) []Token {
+	return s.cd.forward(text)
+}
+
+// Backward cut the text by the backward maximum matching
+func (s *Segmenter) Backward(text []byte) []Token {
+	if s.rev == nil || s.gen != s.cd.gen {
+		s.reverse()
+	}
+	return s.rev.backward(text)
+}
+
+// Bidirectional cut the text by both of the directions and take the better one
+func (s *Segmenter) Bidirectional(text []byte) []Token {
+	fw, bw := s.Forward(text), s.Backward(text)
+	if len(fw) != len(bw) {
+		if len(fw) < len(bw) {
+			return fw
+		}
+		return bw
+	}
+
+	if singles(text, fw) < singles(text, bw) {
+		return fw
+	}
+	return bw
+}
+
+// singles count the tokens of a single rune
+func singles(text []byte, toks []Token) (n int) {
+	for _, tok := range toks {
+		if utf8.RuneCount(text[tok.Start:tok.End]) == 1 {
+			n++
+		}
+	}
+	return
+}
+
+// reverse build the trie of the reversed keys
+func (s *Segmenter) reverse() {
+	s.rev = NewWithOptions(WithReduced(s.cd.Reduced))
+	var rkey []byte
+	s.cd.Walk(func(key []byte, val int) bool {
+		rkey = append(rkey[:0], key...)
+		slices.Reverse(rkey)
+		s.rev.Insert(rkey, val)
+		return true
+	})
+	s.gen = s.cd.gen
+}
+
+// boundary report whether the offset `i` is the boundary of the runes
+func boundary(text []byte, i int) bool {
+	return i == 0 || i == len(text) || utf8.RuneStart(text[i])
+}
+
+// nextBoundary return the first boundary of the runes after `i`,
+// it is the end of the rune at `i` and the stray continuation bytes
+func nextBoundary(text []byte, i int) int {
+	for i++; !boundary(text, i); i++ {
+	}
+	return i
+}
+
+// prevBoundary return the last boundary of the runes before `i`
+func prevBoundary(text []byte, i int) int {
+	for i--; !boundary(text, i); i-- {
+	}
+	return i
+}
+
+func (cd *Cedar) forward(text []byte) (toks []Token) {
+	for i := 0; i < len(text); {
+		tok := Token{Start: i, Value: NoVal}
+		for from, j := 0, i; j < len(text); j++ {
+			to, ok := cd.childOf(from, text[j])
+			if !ok {
+				break
+			}
+			from = to
+
+			if val, err := cd.Value(to); err == nil && boundary(text, j+1) {
+				tok.End, tok.Value = j+1, val
+			}
+		}
+
+		if tok.Value == NoVal {
+			tok.End = nextBoundary(text, i)
+		}
+		toks = append(toks, tok)
+		i = tok.End
+	}
+
+	return
+}
+
+// backward cut the text on the trie of the reversed keys
+func (cd *Cedar) backward(text []byte) (toks []Token) {
+	for i := len(text); i > 0; {
+		tok := Token{End: i, Value: NoVal}
+		for from, j := 0, i-1; j >= 0; j-- {
+			to, ok := cd.childOf(from, text[j])
+			if !ok {
+				break
+			}
+			from = to
+
+			if val, err := cd.Value(to); err == nil && boundary(text, j) {
+				tok.Start, tok.Value = j, val
+			}
+		}
+
+		if tok.Value == NoVal {
+			tok.Start = prevBoundary(text, i)
+		}
+		toks = append(toks, tok)
+		i = tok.Start
+	}
+
+	slices.Reverse(toks)
+	return
+}
@@ -0,0 +1,72 @@
+package cedar
+
+import (
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func tokenStrings(text string, toks []Token) (ss []string) {
+	for _, tok := range toks {
+		ss = append(ss, text[tok.Start:tok.End])
+	}
+	return
+}
+
+func TestTokenize(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range words {
+			err := d.InsertString(word, i)
+			tt.Nil(t, err)
+		}
+		// the key stops in the middle of 太
+		err := d.InsertString("\xe5\xa4", 100)
+		tt.Nil(t, err)
+
+		text := "太阳系的地球abcde太阳"
+		toks := d.Tokenize([]byte(text))
+		tt.Equal(t, []string{"太阳系", "的", "地", "球", "abcd", "e", "太", "阳"},
+			tokenStrings(text, toks))
+		tt.Equal(t, Token{0, 9, 6}, toks[0])
+		tt.Equal(t, Token{9, 12, NoVal}, toks[1])
+		tt.Equal(t, 4, toks[4].Value)
+
+		tt.Equal(t, 0, len(d.Tokenize(nil)))
+		// the invalid byte is a token by itself
+		tt.Equal(t, []Token{{0, 1, 0}, {1, 2, NoVal}, {2, 3, 0}},
+			d.Tokenize([]byte("aa\xffa")[1:]))
+	}
+}
+
+func TestSegmenter(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range []string{"研究", "研究生", "生命", "命", "起源"} {
+			err := d.InsertString(word, i)
+			tt.Nil(t, err)
+		}
+
+		text := "研究生命起源"
+		s := NewSegmenter(d)
+		tt.Equal(t, []string{"研究生", "命", "起源"}, tokenStrings(text, s.Segment([]byte(text))))
+		tt.Equal(t, []string{"研究", "生命", "起源"}, tokenStrings(text, s.Backward([]byte(text))))
+		tt.Equal(t, []string{"研究", "生命", "起源"},
+			tokenStrings(text, NewSegmenter(d, Bidirectional).Segment([]byte(text))))
+
+		text = "我研究生命"
+		toks := NewSegmenter(d, Backward).Segment([]byte(text))
+		tt.Equal(t, []string{"我", "研究", "生命"}, tokenStrings(text, toks))
+		tt.Equal(t, Token{0, 3, NoVal}, toks[0])
+		tt.Equal(t, Token{3, 9, 0}, toks[1])
+
+		// the reversed trie is rebuilt after the dictionary is modified
+		err := d.DeleteString("生命")
+		tt.Nil(t, err)
+		tt.Equal(t, []string{"我", "研究生", "命"}, tokenStrings(text, s.Backward([]byte(text))))
+
+		// fewer tokens wins
+		text = "研究生"
+		tt.Equal(t, []string{"研究生"}, tokenStrings(text, s.Bidirectional([]byte(text))))
+	}
+}
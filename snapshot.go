@@ -0,0 +1,117 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"iter"
+	"sync"
+	"sync/atomic"
+)
+
+// Snapshot is the immutable read-only view of a Cedar, it shares nothing
+// with the Cedar it was taken from, so it is safe for concurrent readers
+// without locking while the Cedar is being updated.
+type Snapshot struct {
+	cd   *Cedar
+	once sync.Once // compute the max values of TopK once
+}
+
+// clone deep copy the trie, the copy is trimmed to the used size.
+func (cd *Cedar) clone() *Cedar {
+	nc := *cd
+	nc.array = append([]Node(nil), cd.array[:cd.size]...)
+	nc.nInfos = append([]NInfo(nil), cd.nInfos[:cd.size]...)
+	nc.blocks = append([]Block(nil), cd.blocks[:cd.size>>8]...)
+	nc.capacity = cd.size
+	nc.maxes, nc.maxGens = nil, nil
+
+	return &nc
+}
+
+// Snapshot take the read-only copy of the current trie
+func (cd *Cedar) Snapshot() *Snapshot {
+	return &Snapshot{cd: cd.clone()}
+}
+
+// Snapshot take the read-only copy of the current trie with the read lock held
+func (sc *SyncCedar) Snapshot() *Snapshot {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.Snapshot()
+}
+
+// Get get the key value
+func (s *Snapshot) Get(key []byte) (int, error) {
+	return s.cd.Get(key)
+}
+
+// Find key from the trie, with `from` as the cursor to traverse the nodes
+func (s *Snapshot) Find(key []byte, from int) (int, error) {
+	return s.cd.Find(key, from)
+}
+
+// Jump jump a node `from` to another node by following the `path`
+func (s *Snapshot) Jump(key []byte, from int) (int, error) {
+	return s.cd.Jump(key, from)
+}
+
+// Value get the path value
+func (s *Snapshot) Value(path int) (int, error) {
+	return s.cd.Value(path)
+}
+
+// Key restore the key of the node `id`
+func (s *Snapshot) Key(id int) ([]byte, error) {
+	return s.cd.Key(id)
+}
+
+// ExactMatch to check if `key` is in the dictionary
+func (s *Snapshot) ExactMatch(key []byte) (int, bool) {
+	return s.cd.ExactMatch(key)
+}
+
+// PrefixMatch return the collection of the common prefix
+// in the dictionary with the `key`
+func (s *Snapshot) PrefixMatch(key []byte, n ...int) []int {
+	return s.cd.PrefixMatch(key, n...)
+}
+
+// PrefixPredict return the list of words in the dictionary
+// that has `key` as their prefix
+func (s *Snapshot) PrefixPredict(key []byte, n ...int) []int {
+	return s.cd.PrefixPredict(key, n...)
+}
+
+// Walk call fn with every key and its value, like Cedar.Walk
+func (s *Snapshot) Walk(fn func(key []byte, val int) bool) {
+	s.cd.Walk(fn)
+}
+
+// All return an iterator over the key/value pairs, like Cedar.All
+func (s *Snapshot) All() iter.Seq2[[]byte, int] {
+	return s.cd.All()
+}
+
+// AtomicSnapshot holds the current version of the Snapshot, the readers
+// Load it without locking and the writer publish the new version by Store.
+// The zero value holds no Snapshot.
+type AtomicSnapshot struct {
+	p atomic.Pointer[Snapshot]
+}
+
+// Load return the current Snapshot, nil if none is stored
+func (a *AtomicSnapshot) Load() *Snapshot {
+	return a.p.Load()
+}
+
+// Store publish the Snapshot as the current version
+func (a *AtomicSnapshot) Store(s *Snapshot) {
+	a.p.Store(s)
+}
+
+// Swap publish the Snapshot as the current version and return the old one
+func (a *AtomicSnapshot) Swap(s *Snapshot) *Snapshot {
+	return a.p.Swap(s)
+}
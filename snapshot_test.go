@@ -0,0 +1,89 @@
+package cedar
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestSnapshot(t *testing.T) {
+	d := New()
+	for i, word := range words {
+		err := d.Insert([]byte(word), i)
+		tt.Nil(t, err)
+	}
+
+	s := d.Snapshot()
+	tt.Nil(t, s.cd.Validate())
+	for i := 0; i < 1000; i++ {
+		err := d.Insert([]byte("key"+strconv.Itoa(i)), i)
+		tt.Nil(t, err)
+	}
+	err := d.Delete([]byte("cedar"))
+	tt.Nil(t, err)
+
+	// the snapshot doesn't see the later writes
+	val, err := s.Get([]byte("cedar"))
+	tt.Nil(t, err)
+	tt.Equal(t, 18, val)
+	_, err = s.Get([]byte("key1"))
+	tt.NotNil(t, err)
+
+	n := 0
+	s.Walk(func(key []byte, val int) bool {
+		n++
+		return true
+	})
+	tt.Equal(t, len(words), n)
+}
+
+func TestAtomicSnapshot(t *testing.T) {
+	var current AtomicSnapshot
+	tt.Nil(t, current.Load())
+
+	d := New()
+	err := d.Insert([]byte("version"), 0)
+	tt.Nil(t, err)
+	current.Store(d.Snapshot())
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				s := current.Load()
+				ver, err := s.Get([]byte("version"))
+				if err != nil {
+					t.Error(err)
+					return
+				}
+
+				// every key of the version is in the snapshot
+				for j := 1; j <= ver; j++ {
+					if _, err := s.Get([]byte("key" + strconv.Itoa(j))); err != nil {
+						t.Error(ver, j, err)
+						return
+					}
+				}
+			}
+		}()
+	}
+
+	for i := 1; i <= 100; i++ {
+		err := d.Insert([]byte("key"+strconv.Itoa(i)), i)
+		tt.Nil(t, err)
+		err = d.Insert([]byte("version"), i)
+		tt.Nil(t, err)
+
+		old := current.Swap(d.Snapshot())
+		tt.NotNil(t, old)
+	}
+	wg.Wait()
+
+	val, err := current.Load().Get([]byte("version"))
+	tt.Nil(t, err)
+	tt.Equal(t, 100, val)
+}
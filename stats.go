@@ -0,0 +1,78 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import "unsafe"
+
+// Stats is the statistics of the trie's memory
+type Stats struct {
+	Keys  int // the number of keys, the same as Len
+	Nodes int // the number of used slots, including the root
+	Free  int // the number of free slots
+	Size  int // the number of slots in the blocks, Nodes + Free
+
+	Capacity     int // the number of allocated slots
+	Blocks       int // the number of blocks in use
+	FullBlocks   int // the number of blocks in the 'Full' list
+	ClosedBlocks int // the number of blocks in the 'Closed' list
+	OpenBlocks   int // the number of blocks in the 'Open' list
+
+	ArrayBytes int // the approximate bytes used by `array`
+	NInfoBytes int // the approximate bytes used by `nInfos`
+	BlockBytes int // the approximate bytes used by `blocks`
+	Bytes      int // the total of the above bytes
+}
+
+// Len return the number of keys in the trie
+func (cd *Cedar) Len() int {
+	return cd.count
+}
+
+// Stats return the statistics of the trie
+func (cd *Cedar) Stats() Stats {
+	st := Stats{
+		Keys:     cd.count,
+		Nodes:    1, // the root is not marked by `check`
+		Size:     cd.size,
+		Capacity: cd.capacity,
+		Blocks:   cd.size >> 8,
+
+		FullBlocks:   cd.countBlocks(cd.blocksHeadFull),
+		ClosedBlocks: cd.countBlocks(cd.blocksHeadClosed),
+		OpenBlocks:   cd.countBlocks(cd.blocksHeadOpen),
+
+		ArrayBytes: cap(cd.array) * int(unsafe.Sizeof(Node{})),
+		NInfoBytes: cap(cd.nInfos) * int(unsafe.Sizeof(NInfo{})),
+		BlockBytes: cap(cd.blocks) * int(unsafe.Sizeof(Block{})),
+	}
+
+	for _, n := range cd.array[1:cd.size] {
+		if n.check >= 0 {
+			st.Nodes++
+		}
+	}
+	st.Free = cd.size - st.Nodes
+	st.Bytes = st.ArrayBytes + st.NInfoBytes + st.BlockBytes
+
+	return st
+}
+
+// countBlocks return the number of blocks in the cyclic linked-list of `head`,
+// the block 0 is not counted, it is in the Full list as the sentinel
+func (cd *Cedar) countBlocks(head int) (n int) {
+	if head == 0 {
+		return 0
+	}
+
+	for idx, i := head, 0; i < len(cd.blocks); i++ {
+		if idx != 0 {
+			n++
+		}
+		if idx = int(cd.blocks[idx].next); idx == head {
+			break
+		}
+	}
+	return n
+}
@@ -0,0 +1,92 @@
+package cedar
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestLen(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		tt.Equal(t, 0, d.Len())
+		for i, word := range words {
+			err := d.Insert([]byte(word), i)
+			tt.Nil(t, err)
+		}
+		tt.Equal(t, len(words), d.Len())
+
+		// the existing key is not counted again
+		err := d.Insert([]byte("abc"), 100)
+		tt.Nil(t, err)
+		err = d.Update([]byte("this"), 1)
+		tt.Nil(t, err)
+		tt.Equal(t, len(words), d.Len())
+
+		err = d.Update([]byte("abcde"), 1)
+		tt.Nil(t, err)
+		tt.Equal(t, len(words)+1, d.Len())
+
+		// the prefix of the keys is not a key
+		err = d.Delete([]byte("太阳"))
+		tt.Equal(t, ErrNoKey, err)
+		err = d.Delete([]byte("abcde"))
+		tt.Nil(t, err)
+		err = d.Delete([]byte("abcde"))
+		tt.Equal(t, ErrNoKey, err)
+		tt.Nil(t, d.Validate())
+		tt.Equal(t, len(words), d.Len())
+
+		val, err := d.Get([]byte("abcdef"))
+		tt.Nil(t, err)
+		tt.Equal(t, 5, val)
+		tt.Equal(t, d.Len(), d.Snapshot().cd.Len())
+	}
+}
+
+func TestStats(t *testing.T) {
+	d := New()
+	for i := 0; i < 10000; i++ {
+		err := d.Insert([]byte("key"+strconv.Itoa(i)), i)
+		tt.Nil(t, err)
+	}
+	for i := 0; i < 10000; i += 2 {
+		err := d.Delete([]byte("key" + strconv.Itoa(i)))
+		tt.Nil(t, err)
+	}
+
+	tt.Nil(t, d.Validate())
+
+	st := d.Stats()
+	tt.Equal(t, 5000, st.Keys)
+	tt.Equal(t, d.size, st.Size)
+	tt.Equal(t, st.Size, st.Nodes+st.Free)
+	tt.Equal(t, st.Size>>8, st.Blocks)
+	// the block 0 is not in the lists
+	tt.Equal(t, st.Blocks-1, st.FullBlocks+st.ClosedBlocks+st.OpenBlocks)
+	tt.Equal(t, st.ArrayBytes+st.NInfoBytes+st.BlockBytes, st.Bytes)
+	tt.True(t, st.Nodes > 5000)
+	tt.True(t, st.Capacity >= st.Size)
+
+	// the random keys fill the blocks, the block 0 is not counted as full
+	d = New()
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		err := d.InsertString(fmt.Sprintf("%016x%016x", r.Uint64(), r.Uint64()), i)
+		tt.Nil(t, err)
+	}
+
+	full := 0
+	for _, b := range d.blocks[1 : d.size>>8] {
+		if b.num == 0 {
+			full++
+		}
+	}
+	st = d.Stats()
+	tt.True(t, full > 0)
+	tt.Equal(t, full, st.FullBlocks)
+	tt.Equal(t, st.Blocks-1, st.FullBlocks+st.ClosedBlocks+st.OpenBlocks)
+}
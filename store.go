@@ -0,0 +1,289 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+const (
+	// magic is the header of the serialized trie
+	magic = "CEDR"
+	// version is the version of the serialized format
+	version = 1
+
+	// maxSize is the max size of the trie, the node index must fit in `check`
+	maxSize = ValLimit &^ 255
+	// chunk is the max number of elements allocated before they are read
+	chunk = 1 << 16
+)
+
+const (
+	flagReduced = 1 << iota
+	flagOrdered
+)
+
+// The serialized format is little-endian, every int is written as int64:
+//
+//	magic    [4]byte "CEDR"
+//	version  uint32
+//	flags    uint8, flagReduced | flagOrdered
+//	capacity, size, maxTrial int64
+//	blocksHeadFull, blocksHeadClosed, blocksHeadOpen int64
+//	count    int64
+//	reject   [257]int64
+//	array    [size]{baseV, check int64}
+//	nInfos   [size]{sibling, child uint8}
+//	blocks   [size >> 8]{prev, next, num, reject, trial, eHead int64}
+
+type encoder struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+	buf [8]byte
+}
+
+func (e *encoder) write(p []byte) {
+	if e.err != nil {
+		return
+	}
+
+	n, err := e.w.Write(p)
+	e.n += int64(n)
+	e.err = err
+}
+
+func (e *encoder) uint8(v uint8) {
+	e.buf[0] = v
+	e.write(e.buf[:1])
+}
+
+func (e *encoder) uint32(v uint32) {
+	binary.LittleEndian.PutUint32(e.buf[:], v)
+	e.write(e.buf[:4])
+}
+
+func (e *encoder) int(v int) {
+	binary.LittleEndian.PutUint64(e.buf[:], uint64(int64(v)))
+	e.write(e.buf[:8])
+}
+
+type decoder struct {
+	r   *bufio.Reader
+	n   int64
+	err error
+	buf [8]byte
+}
+
+func (d *decoder) read(p []byte) {
+	if d.err != nil {
+		return
+	}
+
+	n, err := io.ReadFull(d.r, p)
+	d.n += int64(n)
+	d.err = err
+}
+
+// error return the first error, a truncated data is io.ErrUnexpectedEOF
+func (d *decoder) error() error {
+	if d.err == io.EOF && d.n > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return d.err
+}
+
+func (d *decoder) uint8() uint8 {
+	d.read(d.buf[:1])
+	return d.buf[0]
+}
+
+func (d *decoder) uint32() uint32 {
+	d.read(d.buf[:4])
+	return binary.LittleEndian.Uint32(d.buf[:4])
+}
+
+func (d *decoder) int() int {
+	d.read(d.buf[:8])
+	return int(int64(binary.LittleEndian.Uint64(d.buf[:8])))
+}
+
+// fit narrow the int to the field type T of the layout,
+// it is ErrInvalidData if the int overflows.
+func fit[T ~int | ~int32 | ~int16](d *decoder, v int) T {
+	if int(T(v)) != v && d.err == nil {
+		d.err = ErrInvalidData
+	}
+	return T(v)
+}
+
+// WriteTo write the trie to `w` in the binary format,
+// it implements the io.WriterTo interface.
+func (cd *Cedar) WriteTo(w io.Writer) (int64, error) {
+	e := &encoder{w: bufio.NewWriter(w)}
+
+	e.write([]byte(magic))
+	e.uint32(version)
+
+	var flags uint8
+	if cd.Reduced {
+		flags |= flagReduced
+	}
+	if cd.ordered {
+		flags |= flagOrdered
+	}
+	e.uint8(flags)
+
+	e.int(cd.capacity)
+	e.int(cd.size)
+	e.int(int(cd.maxTrial))
+	e.int(cd.blocksHeadFull)
+	e.int(cd.blocksHeadClosed)
+	e.int(cd.blocksHeadOpen)
+	e.int(cd.count)
+	for _, r := range cd.reject {
+		e.int(int(r))
+	}
+
+	for _, n := range cd.array[:cd.size] {
+		e.int(int(n.baseV))
+		e.int(int(n.check))
+	}
+	for _, n := range cd.nInfos[:cd.size] {
+		e.uint8(n.sibling)
+		e.uint8(n.child)
+	}
+	for _, b := range cd.blocks[:cd.size>>8] {
+		e.int(int(b.prev))
+		e.int(int(b.next))
+		e.int(int(b.num))
+		e.int(int(b.reject))
+		e.int(int(b.trial))
+		e.int(int(b.eHead))
+	}
+
+	if e.err == nil {
+		e.err = e.w.Flush()
+	}
+	return e.n, e.err
+}
+
+// ReadFrom read the trie written by WriteTo from `r`, the loaded trie
+// replaces the current one and can be updated as usual,
+// it implements the io.ReaderFrom interface.
+//
+// The data is checked by Validate, a corrupt data is ErrInvalidData
+// and the current trie is unchanged.
+func (cd *Cedar) ReadFrom(r io.Reader) (int64, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+
+	var head [4]byte
+	d.read(head[:])
+	if d.err != nil {
+		return d.n, d.error()
+	}
+	if string(head[:]) != magic {
+		return d.n, ErrInvalidData
+	}
+	ver := d.uint32()
+	if ver != version && d.err == nil {
+		return d.n, ErrVersion
+	}
+
+	flags := d.uint8()
+	nc := Cedar{
+		Reduced: flags&flagReduced != 0,
+		ordered: flags&flagOrdered != 0,
+
+		capacity: d.int(),
+		size:     d.int(),
+		maxTrial: fit[slotInt](d, d.int()),
+
+		blocksHeadFull:   d.int(),
+		blocksHeadClosed: d.int(),
+		blocksHeadOpen:   d.int(),
+		count:            d.int(),
+	}
+	if d.err != nil {
+		return d.n, d.error()
+	}
+	if nc.size < 256 || nc.size%256 != 0 || nc.size > maxSize ||
+		nc.capacity < nc.size || nc.capacity%256 != 0 || nc.capacity > maxSize ||
+		nc.maxTrial < 1 || nc.maxTrial > 256 || nc.count < 0 {
+		return d.n, ErrInvalidData
+	}
+
+	for i := range nc.reject {
+		nc.reject[i] = fit[slotInt](d, d.int())
+	}
+
+	// the arrays grow with the data instead of allocating the size up front,
+	// so a corrupt size fails at the end of the data
+	nc.array = make([]Node, 0, min(nc.size, chunk))
+	for i := 0; i < nc.size && d.err == nil; i++ {
+		nc.array = append(nc.array, Node{
+			baseV: fit[nodeInt](d, d.int()),
+			check: fit[nodeInt](d, d.int()),
+		})
+	}
+	nc.nInfos = make([]NInfo, 0, min(nc.size, chunk))
+	for i := 0; i < nc.size && d.err == nil; i++ {
+		nc.nInfos = append(nc.nInfos, NInfo{sibling: d.uint8(), child: d.uint8()})
+	}
+	nc.blocks = make([]Block, 0, min(nc.size>>8, chunk))
+	for i := 0; i < nc.size>>8 && d.err == nil; i++ {
+		nc.blocks = append(nc.blocks, Block{
+			prev:   fit[blockInt](d, d.int()),
+			next:   fit[blockInt](d, d.int()),
+			num:    fit[slotInt](d, d.int()),
+			reject: fit[slotInt](d, d.int()),
+			trial:  fit[slotInt](d, d.int()),
+			eHead:  fit[blockInt](d, d.int()),
+		})
+	}
+
+	if d.err != nil {
+		return d.n, d.error()
+	}
+
+	// the spare capacity is allocated again when the trie grows, like Snapshot
+	nc.capacity = nc.size
+	if nc.Validate() != nil {
+		return d.n, ErrInvalidData
+	}
+
+	nc.gen = cd.gen + 1
+	*cd = nc
+	return d.n, nil
+}
+
+// Save write the trie to the file of `path`
+func (cd *Cedar) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = cd.WriteTo(f)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// Load read the trie from the file of `path` written by Save
+func (cd *Cedar) Load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = cd.ReadFrom(f)
+	return err
+}
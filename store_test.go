@@ -0,0 +1,164 @@
+package cedar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestWriteTo(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range words {
+			err := d.Insert([]byte(word), i)
+			tt.Nil(t, err)
+		}
+
+		var buf bytes.Buffer
+		n, err := d.WriteTo(&buf)
+		tt.Nil(t, err)
+		tt.Equal(t, int64(buf.Len()), n)
+
+		ld := New()
+		n, err = ld.ReadFrom(bytes.NewReader(buf.Bytes()))
+		tt.Nil(t, err)
+		tt.Equal(t, int64(buf.Len()), n)
+		tt.Equal(t, reduced, ld.Reduced)
+		tt.Nil(t, ld.Validate())
+		tt.Equal(t, len(words), ld.Len())
+
+		for i, word := range words {
+			val, err := ld.Get([]byte(word))
+			tt.Nil(t, err)
+			tt.Equal(t, i, val)
+		}
+		tt.Equal(t, d.PrefixPredict([]byte("太阳系")), ld.PrefixPredict([]byte("太阳系")))
+
+		// the loaded trie is still updatable
+		err = ld.Insert([]byte("太阳系冥王星"), 100)
+		tt.Nil(t, err)
+		err = ld.Delete([]byte("abc"))
+		tt.Nil(t, err)
+		tt.Nil(t, ld.Validate())
+
+		val, err := ld.Get([]byte("太阳系冥王星"))
+		tt.Nil(t, err)
+		tt.Equal(t, 100, val)
+		_, err = ld.Get([]byte("abc"))
+		tt.NotNil(t, err)
+		val, err = ld.Get([]byte("abcd"))
+		tt.Nil(t, err)
+		tt.Equal(t, 4, val)
+	}
+}
+
+func TestReadFromInvalid(t *testing.T) {
+	d := New()
+	_, err := d.ReadFrom(bytes.NewReader([]byte("cedar trie")))
+	tt.Equal(t, ErrInvalidData, err)
+
+	_, err = d.ReadFrom(bytes.NewReader([]byte("CEDR\x03\x00\x00\x00")))
+	tt.Equal(t, ErrVersion, err)
+
+	var buf bytes.Buffer
+	_, err = New().WriteTo(&buf)
+	tt.Nil(t, err)
+	_, err = d.ReadFrom(bytes.NewReader(buf.Bytes()[:buf.Len()-1]))
+	tt.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestReadFromCorrupt(t *testing.T) {
+	d := New()
+	for i, word := range words {
+		err := d.Insert([]byte(word), i)
+		tt.Nil(t, err)
+	}
+
+	var buf bytes.Buffer
+	_, err := d.WriteTo(&buf)
+	tt.Nil(t, err)
+	data := buf.Bytes()
+
+	// the header is magic, version and flags, then capacity, size and the
+	// other ints, the array starts after the reject
+	const capacity, size, array = 9, 17, 9 + 7*8 + 257*8
+	patch := func(off int, v int64) []byte {
+		b := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint64(b[off:], uint64(v))
+		return b
+	}
+
+	// the capacity is not allocated up front, it is out of
+	// the int32 layout with 1<<60
+	ld := New()
+	_, err = ld.ReadFrom(bytes.NewReader(patch(capacity, 1<<30)))
+	tt.Nil(t, err)
+	tt.Equal(t, d.size, ld.Stats().Capacity)
+	val, err := ld.Get([]byte("cedar"))
+	tt.Nil(t, err)
+	tt.Equal(t, 18, val)
+
+	_, err = ld.ReadFrom(bytes.NewReader(patch(capacity, 1<<60)))
+	tt.Equal(t, int64(ValLimit) < 1<<60, err == ErrInvalidData)
+	tt.Equal(t, d.size, ld.Stats().Capacity)
+	_, err = ld.ReadFrom(bytes.NewReader(patch(size, 1<<40)))
+	tt.NotNil(t, err)
+	_, err = ld.ReadFrom(bytes.NewReader(patch(size, -256)))
+	tt.Equal(t, ErrInvalidData, err)
+
+	// a child of the root with a broken check or base
+	e := d.array[0].base(true) ^ 'c'
+	_, err = ld.ReadFrom(bytes.NewReader(patch(array+e*16+8, 1<<20)))
+	tt.Equal(t, ErrInvalidData, err)
+	_, err = ld.ReadFrom(bytes.NewReader(patch(array+e*16, -(1 << 20))))
+	tt.Equal(t, ErrInvalidData, err)
+	_, err = ld.ReadFrom(bytes.NewReader(patch(array+e*16+8, int64(e))))
+	tt.Equal(t, ErrInvalidData, err)
+
+	// a corrupt data never panics, it is either rejected or valid
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		b := append([]byte(nil), data...)
+		for n := r.Intn(4) + 1; n > 0; n-- {
+			b[8+r.Intn(len(b)-8)] = byte(r.Intn(256))
+		}
+
+		ld := New()
+		if _, err := ld.ReadFrom(bytes.NewReader(b)); err != nil {
+			continue
+		}
+		tt.Nil(t, ld.Validate())
+		for _, word := range words {
+			ld.Get([]byte(word))
+		}
+		ld.Walk(func([]byte, int) bool { return true })
+		tt.Nil(t, ld.Insert([]byte("太阳系冥王星"), 100))
+		tt.Nil(t, ld.Validate())
+	}
+}
+
+func TestSave(t *testing.T) {
+	d := New()
+	for i, word := range words {
+		err := d.Insert([]byte(word), i)
+		tt.Nil(t, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cedar.dat")
+	err := d.Save(path)
+	tt.Nil(t, err)
+
+	ld := New(false)
+	err = ld.Load(path)
+	tt.Nil(t, err)
+	for i, word := range words {
+		val, err := ld.Get([]byte(word))
+		tt.Nil(t, err)
+		tt.Equal(t, i, val)
+	}
+}
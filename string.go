@@ -0,0 +1,55 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import "unsafe"
+
+// bytesOf return the bytes of `s` without the copy,
+// the bytes are read only and must not be kept.
+func bytesOf(s string) []byte {
+	return unsafe.Slice(unsafe.StringData(s), len(s))
+}
+
+// InsertString insert the key for the value on string
+func (cd *Cedar) InsertString(key string, val int) error {
+	return cd.Insert(bytesOf(key), val)
+}
+
+// UpdateString update the key for the value on string
+func (cd *Cedar) UpdateString(key string, val int) error {
+	return cd.Update(bytesOf(key), val)
+}
+
+// GetString get the key value on string
+func (cd *Cedar) GetString(key string) (int, error) {
+	return cd.Get(bytesOf(key))
+}
+
+// DeleteString delete the key on string
+func (cd *Cedar) DeleteString(key string) error {
+	return cd.Delete(bytesOf(key))
+}
+
+// PrefixMatchString is PrefixMatch on string
+func (cd *Cedar) PrefixMatchString(key string, n ...int) []int {
+	return cd.PrefixMatch(bytesOf(key), n...)
+}
+
+// PrefixPredictString is PrefixPredict on string
+func (cd *Cedar) PrefixPredictString(key string, n ...int) []int {
+	return cd.PrefixPredict(bytesOf(key), n...)
+}
+
+// PrefixMatchRune is PrefixMatch, but only the prefix ending on the boundary
+// of the UTF-8 runes is matched, so the hit never stops in the middle
+// of a multi-byte character.
+func (cd *Cedar) PrefixMatchRune(key []byte, n ...int) []int {
+	return cd.prefixMatch(key, true, n...)
+}
+
+// PrefixMatchRuneString is PrefixMatchRune on string
+func (cd *Cedar) PrefixMatchRuneString(key string, n ...int) []int {
+	return cd.PrefixMatchRune(bytesOf(key), n...)
+}
@@ -0,0 +1,73 @@
+package cedar
+
+import (
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestString(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i, word := range words {
+			err := d.InsertString(word, i)
+			tt.Nil(t, err)
+		}
+
+		val, err := d.GetString("太阳系")
+		tt.Nil(t, err)
+		tt.Equal(t, 6, val)
+		err = d.UpdateString("太阳系", 1)
+		tt.Nil(t, err)
+		val, err = d.GetString("太阳系")
+		tt.Nil(t, err)
+		tt.Equal(t, 7, val)
+
+		tt.Equal(t, d.PrefixMatch([]byte("this is a cedar.")),
+			d.PrefixMatchString("this is a cedar."))
+		tt.Equal(t, d.PrefixPredict([]byte("太阳")), d.PrefixPredictString("太阳"))
+		tt.Equal(t, 2, len(d.PrefixMatchString("abcdef", 2)))
+
+		err = d.DeleteString("太阳系")
+		tt.Nil(t, err)
+		_, err = d.GetString("太阳系")
+		tt.NotNil(t, err)
+		err = d.DeleteString("太阳系")
+		tt.Equal(t, ErrNoKey, err)
+		tt.Nil(t, d.Validate())
+
+		allocs := testing.AllocsPerRun(100, func() {
+			d.GetString("太阳系统")
+		})
+		tt.Equal(t, 0, int(allocs))
+	}
+}
+
+func TestPrefixMatchRune(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		// the keys stop in the middle of 太 and 阳
+		for i, key := range []string{"\xe5\xa4", "太", "太\xe9", "太阳", "太阳系"} {
+			err := d.InsertString(key, i)
+			tt.Nil(t, err)
+		}
+
+		vals := func(ids []int) (vs []int) {
+			for _, id := range ids {
+				val, err := d.Value(id)
+				tt.Nil(t, err)
+				vs = append(vs, val)
+			}
+			return
+		}
+
+		tt.Equal(t, []int{0, 1, 2, 3, 4}, vals(d.PrefixMatchString("太阳系")))
+		tt.Equal(t, []int{1, 3, 4}, vals(d.PrefixMatchRuneString("太阳系")))
+		tt.Equal(t, []int{1, 3}, vals(d.PrefixMatchRune([]byte("太阳系"), 2)))
+		tt.Equal(t, []int{1, 3}, vals(d.PrefixMatchRuneString("太阳的")))
+
+		// the end of the key is a boundary, even if the rune is incomplete
+		tt.Equal(t, []int{1, 2}, vals(d.PrefixMatchRuneString("太\xe9")))
+		tt.Equal(t, 0, len(d.PrefixMatchRuneString("月亮")))
+	}
+}
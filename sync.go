@@ -0,0 +1,109 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import "sync"
+
+// SyncCedar is the Cedar safe for concurrent use, the readers share
+// the lock and the writers are serialized.
+//
+// The node ids are only stable until the next write,
+// because the writers may relocate the nodes.
+type SyncCedar struct {
+	mu sync.RWMutex
+	cd *Cedar
+}
+
+// NewSync initialize the SyncCedar for further use
+func NewSync(reduced ...bool) *SyncCedar {
+	return &SyncCedar{cd: New(reduced...)}
+}
+
+// Insert the key for the value
+func (sc *SyncCedar) Insert(key []byte, val int) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.cd.Insert(key, val)
+}
+
+// Update the key for the value
+func (sc *SyncCedar) Update(key []byte, value int) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.cd.Update(key, value)
+}
+
+// Delete the key from the trie
+func (sc *SyncCedar) Delete(key []byte) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.cd.Delete(key)
+}
+
+// Get get the key value
+func (sc *SyncCedar) Get(key []byte) (int, error) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.Get(key)
+}
+
+// Find key from the trie, with `from` as the cursor to traverse the nodes
+func (sc *SyncCedar) Find(key []byte, from int) (int, error) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.Find(key, from)
+}
+
+// Jump jump a node `from` to another node by following the `path`
+func (sc *SyncCedar) Jump(key []byte, from int) (int, error) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.Jump(key, from)
+}
+
+// Value get the path value
+func (sc *SyncCedar) Value(path int) (int, error) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.Value(path)
+}
+
+// Key restore the key of the node `id`
+func (sc *SyncCedar) Key(id int) ([]byte, error) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.Key(id)
+}
+
+// ExactMatch to check if `key` is in the dictionary
+func (sc *SyncCedar) ExactMatch(key []byte) (int, bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.ExactMatch(key)
+}
+
+// PrefixMatch return the collection of the common prefix
+// in the dictionary with the `key`
+func (sc *SyncCedar) PrefixMatch(key []byte, n ...int) []int {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.PrefixMatch(key, n...)
+}
+
+// PrefixPredict return the list of words in the dictionary
+// that has `key` as their prefix
+func (sc *SyncCedar) PrefixPredict(key []byte, n ...int) []int {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	return sc.cd.PrefixPredict(key, n...)
+}
+
+// Walk call fn with every key and its value with the read lock held,
+// fn must not write to the SyncCedar.
+func (sc *SyncCedar) Walk(fn func(key []byte, val int) bool) {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+	sc.cd.Walk(fn)
+}
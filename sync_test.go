@@ -0,0 +1,67 @@
+package cedar
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestSyncCedar(t *testing.T) {
+	sc := NewSync()
+	for i, word := range words {
+		err := sc.Insert([]byte(word), i)
+		tt.Nil(t, err)
+	}
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(2)
+
+		// the writers insert and delete their own keys
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				key := []byte("g" + strconv.Itoa(g) + "/" + strconv.Itoa(i))
+				if err := sc.Insert(key, i); err != nil {
+					t.Error(err)
+				}
+				if i%3 == 0 {
+					if err := sc.Delete(key); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}(g)
+
+		// the readers always see the words
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				word := words[i%len(words)]
+				if val, err := sc.Get([]byte(word)); err != nil || val != i%len(words) {
+					t.Error(word, val, err)
+				}
+
+				if ids := sc.PrefixMatch([]byte("this is a cedar.")); len(ids) != 3 {
+					t.Error(ids)
+				}
+				sc.PrefixPredict([]byte("太阳系"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for g := 0; g < 8; g++ {
+		for i := 0; i < 500; i++ {
+			val, err := sc.Get([]byte("g" + strconv.Itoa(g) + "/" + strconv.Itoa(i)))
+			if i%3 == 0 {
+				tt.NotNil(t, err)
+			} else {
+				tt.Nil(t, err)
+				tt.Equal(t, i, val)
+			}
+		}
+	}
+}
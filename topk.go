@@ -0,0 +1,151 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import "container/heap"
+
+// topItem is the node in the best-first search of TopK, `val` is the value
+// of the key if `key` is true, or the max value of the subtree otherwise.
+type topItem struct {
+	id, val int
+	key     bool
+}
+
+type topHeap []topItem
+
+func (h topHeap) Len() int { return len(h) }
+
+// Less order the items by the value, the key before the subtree of
+// the same value so it is reported at once, then by the node id.
+func (h topHeap) Less(i, j int) bool {
+	if h[i].val != h[j].val {
+		return h[i].val > h[j].val
+	}
+	if h[i].key != h[j].key {
+		return h[i].key
+	}
+	return h[i].id < h[j].id
+}
+
+func (h topHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *topHeap) Push(x any) { *h = append(*h, x.(topItem)) }
+
+func (h *topHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// topStale report whether the max-value annotations of the subtree
+// of `root` are out of date, the stamp of the annotated node is gen+1
+// so the zero stamp is never up to date.
+func (cd *Cedar) topStale(root int) bool {
+	return root >= len(cd.maxGens) || cd.maxGens[root] != cd.gen+1
+}
+
+// annotate compute the max value in the subtree of every node under `root`,
+// the other subtrees are annotated when they are queried.
+func (cd *Cedar) annotate(root int) {
+	if len(cd.maxes) < cd.size {
+		cd.maxes = make([]nodeInt, cd.size)
+		cd.maxGens = make([]uint64, cd.size)
+	}
+	cd.annotateNode(root)
+}
+
+func (cd *Cedar) annotateNode(from int) nodeInt {
+	m := nodeInt(NoVal)
+	if from != 0 {
+		if val, err := cd.Value(from); err == nil {
+			m = nodeInt(val)
+		}
+	}
+
+	cd.eachChild(from, func(to int, label byte) bool {
+		m = max(m, cd.annotateNode(to))
+		return true
+	})
+
+	cd.maxes[from] = m
+	cd.maxGens[from] = cd.gen + 1
+	return m
+}
+
+// TopK return the node ids of the k keys with the highest values
+// which have `prefix` as their prefix, in the descending order of the values,
+// the order of the keys of the same value is unspecified.
+//
+// The max values of the subtree of `prefix` are computed on the first call
+// after the trie is modified, then the search only visits the subtrees that
+// could hold the top keys instead of every key of the prefix. The max values
+// are cached in the trie, so TopK is a write for the concurrent use,
+// SyncCedar and Snapshot handle it.
+func (cd *Cedar) TopK(prefix []byte, k int) []int {
+	root, err := cd.Jump(prefix, 0)
+	if err != nil || k <= 0 {
+		return nil
+	}
+	if cd.topStale(root) {
+		cd.annotate(root)
+	}
+	return cd.topK(root, k)
+}
+
+// topK search the annotated subtree of `root`
+func (cd *Cedar) topK(root, k int) (ids []int) {
+	h := topHeap{{id: root, val: int(cd.maxes[root])}}
+	for len(h) > 0 && len(ids) < k {
+		item := heap.Pop(&h).(topItem)
+		if item.key {
+			ids = append(ids, item.id)
+			continue
+		}
+
+		if item.id != 0 {
+			if val, err := cd.Value(item.id); err == nil {
+				heap.Push(&h, topItem{id: item.id, val: val, key: true})
+			}
+		}
+		cd.eachChild(item.id, func(to int, label byte) bool {
+			if m := int(cd.maxes[to]); m != NoVal {
+				heap.Push(&h, topItem{id: to, val: m})
+			}
+			return true
+		})
+	}
+
+	return
+}
+
+// TopKString is TopK on string
+func (cd *Cedar) TopKString(prefix string, k int) []int {
+	return cd.TopK(bytesOf(prefix), k)
+}
+
+// TopK return the node ids of the k keys with the highest values
+// which have `prefix` as their prefix, the write lock is held
+// only if the max values of the prefix need to be computed.
+func (sc *SyncCedar) TopK(prefix []byte, k int) []int {
+	sc.mu.RLock()
+	if root, err := sc.cd.Jump(prefix, 0); err != nil || k <= 0 || !sc.cd.topStale(root) {
+		defer sc.mu.RUnlock()
+		return sc.cd.TopK(prefix, k)
+	}
+	sc.mu.RUnlock()
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	return sc.cd.TopK(prefix, k)
+}
+
+// TopK return the node ids of the k keys with the highest values
+// which have `prefix` as their prefix
+func (s *Snapshot) TopK(prefix []byte, k int) []int {
+	// the snapshot is never modified, so the whole trie is annotated once
+	s.once.Do(func() { s.cd.annotate(0) })
+	return s.cd.TopK(prefix, k)
+}
@@ -0,0 +1,123 @@
+package cedar
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func topValues(t *testing.T, d *Cedar, ids []int) (vals []int) {
+	for _, id := range ids {
+		val, err := d.Value(id)
+		tt.Nil(t, err)
+		vals = append(vals, val)
+	}
+	return
+}
+
+func TestTopK(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		for i := 0; i < 5000; i++ {
+			err := d.Insert([]byte("key"+strconv.Itoa(r.Intn(20000))), r.Intn(1000))
+			tt.Nil(t, err)
+		}
+
+		for _, prefix := range []string{"", "key", "key1", "key12", "key123", "key1234"} {
+			var want []int
+			d.Walk(func(key []byte, val int) bool {
+				if bytes.HasPrefix(key, []byte(prefix)) {
+					want = append(want, val)
+				}
+				return true
+			})
+			sort.Sort(sort.Reverse(sort.IntSlice(want)))
+
+			ids := d.TopK([]byte(prefix), 10)
+			tt.Equal(t, want[:min(10, len(want))], topValues(t, d, ids))
+			for _, id := range ids {
+				key, err := d.Key(id)
+				tt.Nil(t, err)
+				tt.True(t, bytes.HasPrefix(key, []byte(prefix)))
+			}
+		}
+
+		tt.Equal(t, 0, len(d.TopK([]byte("key"), 0)))
+		tt.Equal(t, 0, len(d.TopK([]byte("yek"), 10)))
+
+		// the max values are computed again after the trie is modified,
+		// only for the subtree of the prefix
+		err := d.Insert([]byte("key1x"), 5000)
+		tt.Nil(t, err)
+		ids := d.TopKString("key1", 1)
+		key, _ := d.Key(ids[0])
+		tt.Equal(t, "key1x", string(key))
+		root, _ := d.Jump([]byte("key1"), 0)
+		tt.False(t, d.topStale(root))
+		tt.True(t, d.topStale(0))
+
+		err = d.Delete([]byte("key1x"))
+		tt.Nil(t, err)
+		tt.Equal(t, d.PrefixMatch([]byte("key1x")), d.TopK([]byte("key1x"), 1))
+		tt.True(t, topValues(t, d, d.TopK([]byte("key1"), 1))[0] < 1000)
+	}
+}
+
+func TestTopKWords(t *testing.T) {
+	d := New()
+	for i, word := range words {
+		err := d.InsertString(word, i)
+		tt.Nil(t, err)
+	}
+
+	tt.Equal(t, []int{14, 13, 12}, topValues(t, d, d.TopKString("太阳", 3)))
+	tt.Equal(t, []int{5, 4, 3, 2, 1, 0}, topValues(t, d, d.TopKString("a", 10)))
+
+	sc := NewSync()
+	for i, word := range words {
+		err := sc.Insert([]byte(word), i)
+		tt.Nil(t, err)
+	}
+	tt.Equal(t, 3, len(sc.TopK([]byte("太阳"), 3)))
+
+	s := sc.Snapshot()
+	tt.Equal(t, d.TopKString("this", 3), s.TopK([]byte("this"), 3))
+	err := sc.Insert([]byte("this is new"), 100)
+	tt.Nil(t, err)
+	tt.Equal(t, []int{17, 16, 15}, topValues(t, s.cd, s.TopK([]byte("this"), 3)))
+	ids := sc.TopK([]byte("this"), 3)
+	val, err := sc.Value(ids[0])
+	tt.Nil(t, err)
+	tt.Equal(t, 100, val)
+}
+
+func TestTopKSync(t *testing.T) {
+	sc := NewSync()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				sc.TopK([]byte("key"), 5)
+			}
+		}()
+	}
+
+	for i := 0; i < 1000; i++ {
+		err := sc.Insert([]byte("key"+strconv.Itoa(i)), i)
+		tt.Nil(t, err)
+	}
+	wg.Wait()
+
+	ids := sc.TopK([]byte("key"), 1)
+	val, err := sc.Value(ids[0])
+	tt.Nil(t, err)
+	tt.Equal(t, 999, val)
+}
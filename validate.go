@@ -0,0 +1,303 @@
+// Copyright 2016 Evans. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cedar
+
+import "fmt"
+
+func corrupt(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: "+format, append([]interface{}{ErrCorrupt}, args...)...)
+}
+
+// Validate check the structural invariants of the double array, it returns
+// the ErrCorrupt with the detail when the trie is broken:
+//
+//   - every used node's `check` points to a used parent that owns its slot;
+//   - every used node reaches the root and its `base` is in the array;
+//   - every empty node and terminal node has no `child`;
+//   - every parent's `child` and `sibling` chain matches its actual children;
+//   - the empty ring of every block is consistent with `num` and `eHead`;
+//   - every block is in the right 'Full', 'Closed' or 'Open' list.
+func (cd *Cedar) Validate() error {
+	if err := cd.validateSize(); err != nil {
+		return err
+	}
+	if err := cd.validateNodes(); err != nil {
+		return err
+	}
+	if err := cd.validatePaths(); err != nil {
+		return err
+	}
+	if err := cd.validateBlocks(); err != nil {
+		return err
+	}
+	return cd.validateLists()
+}
+
+func (cd *Cedar) validateSize() error {
+	if cd.size < 256 || cd.size%256 != 0 || cd.size > cd.capacity {
+		return corrupt("size %d, capacity %d", cd.size, cd.capacity)
+	}
+	if len(cd.array) != cd.capacity || len(cd.nInfos) != cd.capacity ||
+		len(cd.blocks) != cd.capacity>>8 {
+		return corrupt("capacity %d, array %d, nInfos %d, blocks %d",
+			cd.capacity, len(cd.array), len(cd.nInfos), len(cd.blocks))
+	}
+
+	// the root stays at the base 0 and it is its own terminal node
+	if cd.array[0].check >= 0 || cd.array[0].base(cd.Reduced) != 0 {
+		return corrupt("root %+v", cd.array[0])
+	}
+	return nil
+}
+
+// isTerminal reports whether the used node `e` is the terminal node of label 0
+func (cd *Cedar) isTerminal(e int) bool {
+	p := int(cd.array[e].check)
+	return e != 0 && p < cd.size && e == cd.array[p].base(cd.Reduced)
+}
+
+func (cd *Cedar) validateNodes() error {
+	children := make([]int16, cd.size)
+	for e := 1; e < cd.size; e++ {
+		if cd.array[e].check < 0 {
+			// the empty node is reset when it is pushed to the empty ring
+			if cd.nInfos[e] != (NInfo{}) {
+				return corrupt("empty node %d has the info %+v", e, cd.nInfos[e])
+			}
+			continue
+		}
+
+		p := int(cd.array[e].check)
+		if p >= cd.size || p != 0 && cd.array[p].check < 0 {
+			return corrupt("node %d has the parent %d not in use", e, p)
+		}
+		if p != 0 && cd.isTerminal(p) {
+			return corrupt("node %d has the terminal parent %d", e, p)
+		}
+		if cd.Reduced && cd.array[p].baseV >= 0 {
+			return corrupt("node %d has the leaf parent %d", e, p)
+		}
+
+		base := cd.array[p].base(cd.Reduced)
+		if base < 0 || (e^base)>>8 != 0 {
+			return corrupt("node %d is not in the children of %d, base %d", e, p, base)
+		}
+		if !cd.isTerminal(e) && cd.array[e].base(cd.Reduced) >= cd.size {
+			return corrupt("node %d has the base %d out of the size", e, cd.array[e].base(cd.Reduced))
+		}
+		children[p]++
+	}
+
+	for e := 0; e < cd.size; e++ {
+		if e != 0 && cd.array[e].check < 0 {
+			continue
+		}
+		if cd.isTerminal(e) {
+			if cd.nInfos[e].child != 0 {
+				return corrupt("terminal node %d has the child %d", e, cd.nInfos[e].child)
+			}
+			continue
+		}
+
+		if err := cd.validateChain(e, int(children[e])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePaths check every used node reaches the root by its parents,
+// the `state` is 1 for the nodes on the current path and 2 for the checked.
+func (cd *Cedar) validatePaths() error {
+	state := make([]byte, cd.size)
+	state[0] = 2
+
+	var path []int
+	for e := 1; e < cd.size; e++ {
+		if cd.array[e].check < 0 {
+			continue
+		}
+
+		p := e
+		for ; state[p] == 0; p = int(cd.array[p].check) {
+			state[p] = 1
+			path = append(path, p)
+		}
+		if state[p] == 1 {
+			return corrupt("node %d is in a cycle of the parents", p)
+		}
+
+		for _, p := range path {
+			state[p] = 2
+		}
+		path = path[:0]
+	}
+	return nil
+}
+
+// validateChain check the sibling chain of `from` has exactly `n` children
+func (cd *Cedar) validateChain(from, n int) error {
+	if n == 0 {
+		if cd.nInfos[from].child != 0 {
+			return corrupt("node %d has no children but the child %d", from, cd.nInfos[from].child)
+		}
+		return nil
+	}
+
+	base := cd.array[from].base(cd.Reduced)
+	c, m := cd.nInfos[from].child, 0
+	if from == 0 {
+		if c != 0 {
+			return corrupt("root has the child %d", c)
+		}
+		c = cd.nInfos[0].sibling
+	} else if c == 0 {
+		m++
+		if int(cd.array[base].check) != from {
+			return corrupt("node %d has no terminal node at %d", from, base)
+		}
+		c = cd.nInfos[base].sibling
+	}
+
+	for prev := -1; c != 0; c = cd.nInfos[base^int(c)].sibling {
+		to := base ^ int(c)
+		if int(cd.array[to].check) != from {
+			return corrupt("node %d has the child %d at %d owned by %d", from, c, to, cd.array[to].check)
+		}
+		if cd.ordered && int(c) <= prev {
+			return corrupt("node %d has the child %d after %d", from, c, prev)
+		}
+
+		prev = int(c)
+		if m++; m > n {
+			break
+		}
+	}
+
+	if m != n {
+		return corrupt("node %d has %d children but %d in the chain", from, n, m)
+	}
+	return nil
+}
+
+func (cd *Cedar) validateBlocks() error {
+	for i, r := range cd.reject {
+		if r < 0 || r > 257 {
+			return corrupt("reject %d is %d", i, r)
+		}
+	}
+
+	for idx := 0; idx < cd.size>>8; idx++ {
+		b := &cd.blocks[idx]
+		lo := idx << 8
+		if int(b.prev) < 0 || int(b.prev) >= cd.size>>8 ||
+			int(b.next) < 0 || int(b.next) >= cd.size>>8 {
+			return corrupt("block %d has the prev %d and next %d out of the size", idx, b.prev, b.next)
+		}
+		if b.reject < 0 || b.reject > 257 || b.trial < 0 || b.trial > cd.maxTrial {
+			return corrupt("block %d has the reject %d and trial %d out of range", idx, b.reject, b.trial)
+		}
+
+		free := 0
+		for e := lo; e < lo+256; e++ {
+			if cd.array[e].check < 0 && e != 0 {
+				free++
+			}
+		}
+
+		// the root slot is counted as free in the block 0
+		num := int(b.num)
+		if idx == 0 {
+			num--
+		}
+		if num != free {
+			return corrupt("block %d has %d free slots but num %d", idx, free, b.num)
+		}
+		if free == 0 {
+			continue
+		}
+
+		head := int(b.eHead)
+		if head < lo || head >= lo+256 || cd.array[head].check >= 0 {
+			return corrupt("block %d has the eHead %d not free", idx, head)
+		}
+
+		e, n := head, 0
+		for {
+			next := -int(cd.array[e].check)
+			if next < lo || next >= lo+256 || cd.array[next].check >= 0 {
+				return corrupt("block %d has the empty node %d linked to %d", idx, e, next)
+			}
+			if -int(cd.array[next].baseV) != e {
+				return corrupt("block %d has the empty node %d linked back to %d", idx, next, -cd.array[next].baseV)
+			}
+
+			e = next
+			if n++; e == head || n > free {
+				break
+			}
+		}
+
+		if n != free {
+			return corrupt("block %d has %d free slots but %d in the empty ring", idx, free, n)
+		}
+	}
+	return nil
+}
+
+func (cd *Cedar) validateLists() error {
+	seen := make([]bool, cd.size>>8)
+	lists := []struct {
+		name string
+		head int
+		ok   func(b *Block) bool
+	}{
+		{"Full", cd.blocksHeadFull, func(b *Block) bool { return b.num == 0 }},
+		{"Closed", cd.blocksHeadClosed, func(b *Block) bool { return b.num > 0 }},
+		{"Open", cd.blocksHeadOpen, func(b *Block) bool { return b.num > 1 && b.trial < cd.maxTrial }},
+	}
+
+	for _, l := range lists {
+		if l.head == 0 {
+			continue
+		}
+
+		idx := l.head
+		for n := 0; ; n++ {
+			if idx < 0 || idx >= len(seen) || seen[idx] && idx != 0 || n > len(seen) {
+				return corrupt("block %d is not in the %s list", idx, l.name)
+			}
+			seen[idx] = true
+
+			// the block 0 is the sentinel of the 'Full' list, because the
+			// empty 'Full' list is pushed as a non-empty one like the C++ cedar
+			if idx == 0 && l.name != "Full" {
+				return corrupt("block 0 is in the %s list", l.name)
+			}
+
+			b := &cd.blocks[idx]
+			if idx != 0 && !l.ok(b) {
+				return corrupt("block %d with num %d and trial %d is in the %s list",
+					idx, b.num, b.trial, l.name)
+			}
+			if int(cd.blocks[b.next].prev) != idx {
+				return corrupt("block %d has the next %d linked back to %d",
+					idx, b.next, cd.blocks[b.next].prev)
+			}
+
+			idx = int(b.next)
+			if idx == l.head {
+				break
+			}
+		}
+	}
+
+	for idx := 1; idx < len(seen); idx++ {
+		if !seen[idx] {
+			return corrupt("block %d is not in any list", idx)
+		}
+	}
+	return nil
+}
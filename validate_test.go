@@ -0,0 +1,57 @@
+package cedar
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"testing"
+
+	"github.com/vcaesar/tt"
+)
+
+func TestValidate(t *testing.T) {
+	for _, reduced := range []bool{true, false} {
+		d := New(reduced)
+		tt.Nil(t, d.Validate())
+
+		r := rand.New(rand.NewSource(1))
+		for i := 0; i < 2000; i++ {
+			key := []byte("k" + strconv.Itoa(r.Intn(500)) + "/" + strconv.Itoa(r.Intn(5)))
+			if r.Intn(3) == 0 {
+				d.Delete(key)
+			} else {
+				err := d.Insert(key, i)
+				tt.Nil(t, err)
+			}
+
+			if err := d.Validate(); err != nil {
+				t.Fatal(i, err)
+			}
+		}
+	}
+}
+
+func TestValidateCorrupt(t *testing.T) {
+	corrupts := []func(d *Cedar){
+		func(d *Cedar) { d.array[d.array[0].base(d.Reduced)^'a'].check = 300 },
+		func(d *Cedar) { d.nInfos[0].sibling = 'z' },
+		func(d *Cedar) { d.blocks[1].num++ },
+		func(d *Cedar) { d.blocks[1].eHead = 256 + 'a' },
+		func(d *Cedar) { d.blocksHeadOpen = 0 },
+		func(d *Cedar) { d.array = d.array[:len(d.array)-1] },
+	}
+
+	for i, fn := range corrupts {
+		d := New()
+		for i, word := range words {
+			err := d.Insert([]byte(word), i)
+			tt.Nil(t, err)
+		}
+		tt.Nil(t, d.Validate())
+
+		fn(d)
+		if err := d.Validate(); !errors.Is(err, ErrCorrupt) {
+			t.Error(i, err)
+		}
+	}
+}